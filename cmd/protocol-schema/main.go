@@ -0,0 +1,34 @@
+// Command protocol-schema writes the JSON Schema of the WebSocket protocol,
+// generated from the Go types of its messages.
+//
+//	go run ./cmd/protocol-schema -o docs/protocol.schema.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"quiz-system/internal/quiz"
+	"quiz-system/pkg/websocket"
+)
+
+func main() {
+	output := flag.String("o", "", "file to write the schema to (default stdout)")
+	flag.Parse()
+
+	server := append(append([]websocket.MessageSpec{}, websocket.ServerMessages...), quiz.Messages...)
+	schema, err := json.MarshalIndent(websocket.Schema(websocket.ClientMessages, server), "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode schema: %v", err)
+	}
+	schema = append(schema, '\n')
+
+	if *output == "" {
+		os.Stdout.Write(schema)
+		return
+	}
+	if err := os.WriteFile(*output, schema, 0o644); err != nil {
+		log.Fatalf("Failed to write schema: %v", err)
+	}
+}
@@ -41,15 +41,7 @@ func main() {
     if err != nil {
         log.Fatalf("Failed to connect to database: %v", err)
     }
-    err = db.AutoMigrate(
-        &models.User{},
-        &models.Quiz{},
-        &models.Question{},
-        &models.Option{},
-        &models.UserQuizResponse{},
-        &models.UserQuizProgress{},
-        &models.QuizParticipant{},  // Add this line
-    )
+    err = models.Migrate(db)
     if err != nil {
         log.Fatalf("Failed to migrate database: %v", err)
     }
@@ -58,8 +50,9 @@ func main() {
 
     // Initialize WebSocket hub
     wsHub := websocket.NewHub()
-    // In main.go where you initialize the hub
-    go wsHub.Run()
+    // Rooms are shared through Redis so several instances can serve one quiz.
+    wsHub.SetBroker(websocket.NewRedisBroker(redisCache.Client()))
+    wsHub.SetPresence(websocket.NewRedisPresence(redisCache.Client()))
 
     // Initialize repositories
     authRepo := auth.NewRepository(db)
@@ -70,9 +63,11 @@ func main() {
     authService := auth.NewService(authRepo, jwtSecret)
     quizService := quiz.NewService(quizRepo, redisCache, wsHub)
     wsHub.SetQuizService(quizService)
-
-    go wsHub.Run()
-
+    wsHub.SetSubprotocols(auth.TokenSubprotocol)
+    wsHub.SetAuthenticator(auth.WebSocketAuthenticator(jwtSecret))
+    wsHub.SetErrorCoder(quiz.ErrorCode)
+    wsHub.SetScreenAuthenticator(auth.ScreenAuthenticator(jwtSecret))
+    quizService.SetScreenTokenIssuer(auth.ScreenTokenIssuer(jwtSecret))
 
     // Initialize handlers
     authHandler := auth.NewHandler(authService)
@@ -104,11 +99,47 @@ func main() {
 
     apiRouter.HandleFunc("/quiz/my-quizzes", quizHandler.GetMyQuizzes).Methods("GET")
     apiRouter.HandleFunc("/quiz", quizHandler.CreateQuiz).Methods("POST", "OPTIONS")
-    apiRouter.HandleFunc("/quiz/{quizCode}/leaderboard", quizHandler.GetLeaderboard).Methods("GET")
-    apiRouter.HandleFunc("/quiz/{quizCode}/start", quizHandler.StartQuiz).Methods("POST")  // Add this
+    apiRouter.HandleFunc("/quiz/{code}/leaderboard", quizHandler.GetLeaderboard).Methods("GET")
+    apiRouter.HandleFunc("/quiz/{code}/start", quizHandler.StartQuiz).Methods("POST")  // Add this
+    apiRouter.HandleFunc("/quiz/{quizCode}/sessions", quizHandler.CreateSession).Methods("POST", "OPTIONS")
+    apiRouter.HandleFunc("/quiz/{quizCode}/sessions", quizHandler.GetSessions).Methods("GET")
     apiRouter.HandleFunc("/quiz/{quizCode}", quizHandler.GetQuiz).Methods("GET", "OPTIONS")
-    apiRouter.HandleFunc("/quiz/{quizCode}/join", quizHandler.JoinQuiz).Methods("POST", "OPTIONS")
+    apiRouter.HandleFunc("/quiz/{quizCode}", quizHandler.UpdateQuiz).Methods("PUT")
+    apiRouter.HandleFunc("/quiz/{quizCode}", quizHandler.DeleteQuiz).Methods("DELETE")
+    apiRouter.HandleFunc("/quiz/{quizCode}/duplicate", quizHandler.DuplicateQuiz).Methods("POST")
+    apiRouter.HandleFunc("/quiz/{quizCode}/questions", quizHandler.AddQuestion).Methods("POST")
+    apiRouter.HandleFunc("/quiz/{quizCode}/questions/order", quizHandler.ReorderQuestions).Methods("PUT")
+    apiRouter.HandleFunc("/quiz/{quizCode}/questions/{questionID:[0-9]+}", quizHandler.UpdateQuestion).Methods("PUT")
+    apiRouter.HandleFunc("/quiz/{quizCode}/questions/{questionID:[0-9]+}", quizHandler.DeleteQuestion).Methods("DELETE")
+    apiRouter.HandleFunc("/quiz/{quizCode}/questions/{questionID:[0-9]+}/options", quizHandler.AddOption).Methods("POST")
+    apiRouter.HandleFunc("/quiz/{quizCode}/questions/{questionID:[0-9]+}/options/order", quizHandler.ReorderOptions).Methods("PUT")
+    apiRouter.HandleFunc("/quiz/{quizCode}/questions/{questionID:[0-9]+}/options/{optionID:[0-9]+}", quizHandler.UpdateOption).Methods("PUT")
+    apiRouter.HandleFunc("/quiz/{quizCode}/questions/{questionID:[0-9]+}/options/{optionID:[0-9]+}", quizHandler.DeleteOption).Methods("DELETE")
+    apiRouter.HandleFunc("/quiz/{code}/join", quizHandler.JoinQuiz).Methods("POST", "OPTIONS")
     apiRouter.HandleFunc("/quiz/answer", quizHandler.SubmitAnswer).Methods("POST", "OPTIONS")
+
+    // Session routes - {code} is a session join code
+    apiRouter.HandleFunc("/session/{code}", quizHandler.GetSession).Methods("GET")
+    apiRouter.HandleFunc("/session/{code}/join", quizHandler.JoinQuiz).Methods("POST", "OPTIONS")
+    apiRouter.HandleFunc("/session/{code}/open", quizHandler.OpenSession).Methods("POST")
+    apiRouter.HandleFunc("/session/{code}/start", quizHandler.StartQuiz).Methods("POST")
+    apiRouter.HandleFunc("/session/{code}/reveal", quizHandler.RevealResults).Methods("POST")
+    apiRouter.HandleFunc("/session/{code}/next", quizHandler.NextQuestion).Methods("POST")
+    apiRouter.HandleFunc("/session/{code}/pause", quizHandler.PauseSession).Methods("POST")
+    apiRouter.HandleFunc("/session/{code}/resume", quizHandler.ResumeSession).Methods("POST")
+    apiRouter.HandleFunc("/session/{code}/skip", quizHandler.SkipQuestion).Methods("POST")
+    apiRouter.HandleFunc("/session/{code}/end", quizHandler.EndSession).Methods("POST")
+    apiRouter.HandleFunc("/session/{code}/participants/{userID:[0-9]+}/kick", quizHandler.KickParticipant).Methods("POST")
+    apiRouter.HandleFunc("/session/{code}/participants/{userID:[0-9]+}/ban", quizHandler.BanParticipant).Methods("POST")
+    apiRouter.HandleFunc("/session/{code}/archive", quizHandler.ArchiveSession).Methods("POST")
+    apiRouter.HandleFunc("/session/{code}/screen-token", quizHandler.CreateScreenToken).Methods("POST")
+    apiRouter.HandleFunc("/session/{code}/leaderboard", quizHandler.GetLeaderboard).Methods("GET")
+    apiRouter.HandleFunc("/session/{code}/leaderboard/teams", quizHandler.GetTeamLeaderboard).Methods("GET")
+    apiRouter.HandleFunc("/session/{code}/teams", quizHandler.GetTeams).Methods("GET")
+    apiRouter.HandleFunc("/session/{code}/teams", quizHandler.CreateTeam).Methods("POST")
+    apiRouter.HandleFunc("/session/{code}/teams/balance", quizHandler.BalanceTeams).Methods("POST")
+    apiRouter.HandleFunc("/session/{code}/teams/{teamID:[0-9]+}/join", quizHandler.ChooseTeam).Methods("POST")
+    apiRouter.HandleFunc("/session/{code}/participants/{userID:[0-9]+}/team", quizHandler.AssignTeam).Methods("PUT")
     // WebSocket endpoint
     router.HandleFunc("/ws/{quizCode}", wsHub.HandleWebSocket)
     // In main.go where routes are defined
@@ -3,11 +3,110 @@ package auth
 
 import (
     "context"
+    "errors"
     "net/http"
     "strings"
     "github.com/dgrijalva/jwt-go"
 )
 
+// TokenSubprotocol is the WebSocket subprotocol browsers use to carry the JWT,
+// since they cannot set an Authorization header on the handshake. The client
+// offers ["bearer", "<token>"] and the server echoes "bearer" back.
+const TokenSubprotocol = "bearer"
+
+// Claims is the identity carried by a validated token.
+type Claims struct {
+    UserID   uint
+    Username string
+}
+
+// ParseToken validates a signed token and extracts the user claims from it.
+func ParseToken(tokenString, jwtSecret string) (*Claims, error) {
+    claims, err := parseClaims(tokenString, jwtSecret)
+    if err != nil {
+        return nil, err
+    }
+
+    userID, ok := (*claims)["user_id"].(float64)
+    if !ok {
+        return nil, errors.New("invalid user ID in token")
+    }
+    username, _ := (*claims)["username"].(string)
+
+    return &Claims{UserID: uint(userID), Username: username}, nil
+}
+
+// parseClaims validates the signature and expiry of a token and returns its claims.
+func parseClaims(tokenString, jwtSecret string) (*jwt.MapClaims, error) {
+    token, err := jwt.ParseWithClaims(tokenString, &jwt.MapClaims{}, func(token *jwt.Token) (interface{}, error) {
+        if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+            return nil, errors.New("unexpected signing method")
+        }
+        return []byte(jwtSecret), nil
+    })
+    if err != nil {
+        return nil, errors.New("invalid token")
+    }
+
+    claims, ok := token.Claims.(*jwt.MapClaims)
+    if !ok || !token.Valid {
+        return nil, errors.New("invalid token claims")
+    }
+    return claims, nil
+}
+
+// TokenFromRequest extracts a bearer token from the Authorization header, the
+// WebSocket subprotocol list or the "token" query parameter, in that order.
+func TokenFromRequest(r *http.Request) (string, error) {
+    if authHeader := r.Header.Get("Authorization"); authHeader != "" {
+        bearerToken := strings.Split(authHeader, " ")
+        if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
+            return "", errors.New("invalid token format")
+        }
+        return bearerToken[1], nil
+    }
+
+    protocols := websocketProtocols(r)
+    for i, p := range protocols {
+        if p == TokenSubprotocol && i+1 < len(protocols) {
+            return protocols[i+1], nil
+        }
+    }
+
+    if token := r.URL.Query().Get("token"); token != "" {
+        return token, nil
+    }
+    return "", errors.New("authorization token required")
+}
+
+func websocketProtocols(r *http.Request) []string {
+    var protocols []string
+    for _, header := range r.Header.Values("Sec-WebSocket-Protocol") {
+        for _, p := range strings.Split(header, ",") {
+            if p = strings.TrimSpace(p); p != "" {
+                protocols = append(protocols, p)
+            }
+        }
+    }
+    return protocols
+}
+
+// WebSocketAuthenticator returns a function that validates the token on a
+// WebSocket handshake request and yields the authenticated user.
+func WebSocketAuthenticator(jwtSecret string) func(r *http.Request) (uint, string, error) {
+    return func(r *http.Request) (uint, string, error) {
+        tokenString, err := TokenFromRequest(r)
+        if err != nil {
+            return 0, "", err
+        }
+        claims, err := ParseToken(tokenString, jwtSecret)
+        if err != nil {
+            return 0, "", err
+        }
+        return claims.UserID, claims.Username, nil
+    }
+}
+
 // backend/internal/auth/middleware.go
 func JWTMiddleware(jwtSecret string) func(http.Handler) http.Handler {
     return func(next http.Handler) http.Handler {
@@ -24,29 +123,14 @@ func JWTMiddleware(jwtSecret string) func(http.Handler) http.Handler {
                 return
             }
 
-            token, err := jwt.ParseWithClaims(bearerToken[1], &jwt.MapClaims{}, func(token *jwt.Token) (interface{}, error) {
-                return []byte(jwtSecret), nil
-            })
-
+            claims, err := ParseToken(bearerToken[1], jwtSecret)
             if err != nil {
                 http.Error(w, "Invalid token", http.StatusUnauthorized)
                 return
             }
 
-            claims, ok := token.Claims.(*jwt.MapClaims)
-            if !ok || !token.Valid {
-                http.Error(w, "Invalid token claims", http.StatusUnauthorized)
-                return
-            }
-
-            userID, ok := (*claims)["user_id"].(float64)
-            if !ok {
-                http.Error(w, "Invalid user ID in token", http.StatusUnauthorized)
-                return
-            }
-
-            ctx := context.WithValue(r.Context(), "user_id", uint(userID))
+            ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
             next.ServeHTTP(w, r.WithContext(ctx))
         })
     }
-}
\ No newline at end of file
+}
@@ -0,0 +1,66 @@
+// backend/internal/auth/screen.go
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// ScreenTokenParam is the query parameter a spectator display passes its
+// screen token in, for example /ws/ABCD1234?screen=<token>.
+const ScreenTokenParam = "screen"
+
+// ScreenTokenTTL is how long a screen token stays valid.
+const ScreenTokenTTL = 12 * time.Hour
+
+// NewScreenToken signs a read-only token that lets a display watch the
+// session with the given join code. It carries no user, so it is refused
+// everywhere a user token is needed.
+func NewScreenToken(joinCode, jwtSecret string, expiresAt time.Time) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"screen": joinCode,
+		"exp":    expiresAt.Unix(),
+	})
+	return token.SignedString([]byte(jwtSecret))
+}
+
+// ParseScreenToken validates a screen token and returns the join code of the
+// session it is for.
+func ParseScreenToken(tokenString, jwtSecret string) (string, error) {
+	claims, err := parseClaims(tokenString, jwtSecret)
+	if err != nil {
+		return "", err
+	}
+	joinCode, ok := (*claims)["screen"].(string)
+	if !ok || joinCode == "" {
+		return "", errors.New("not a screen token")
+	}
+	return joinCode, nil
+}
+
+// ScreenTokenIssuer returns a function that mints screen tokens valid for
+// ScreenTokenTTL.
+func ScreenTokenIssuer(jwtSecret string) func(joinCode string) (string, time.Time, error) {
+	return func(joinCode string) (string, time.Time, error) {
+		expiresAt := time.Now().Add(ScreenTokenTTL)
+		token, err := NewScreenToken(joinCode, jwtSecret, expiresAt)
+		return token, expiresAt, err
+	}
+}
+
+// ScreenAuthenticator returns a function that validates the screen token of a
+// WebSocket handshake request and yields the join code it is for. ok is false
+// for requests without a screen token.
+func ScreenAuthenticator(jwtSecret string) func(r *http.Request) (joinCode string, ok bool, err error) {
+	return func(r *http.Request) (string, bool, error) {
+		tokenString := r.URL.Query().Get(ScreenTokenParam)
+		if tokenString == "" {
+			return "", false, nil
+		}
+		joinCode, err := ParseScreenToken(tokenString, jwtSecret)
+		return joinCode, true, err
+	}
+}
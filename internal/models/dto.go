@@ -4,9 +4,12 @@ package models
 // models/dto.go
 type QuestionDTO struct {
     ID            uint       `json:"id"`
+    Type          QuestionType `json:"type"`
     Text          string     `json:"text"`
     Options       []OptionDTO `json:"options"`
     TimeLimit     int        `json:"time_limit"`
+    Tolerance     float64    `json:"tolerance,omitempty"`
+    Weight        float64    `json:"weight"`
     CorrectAnswer string     `json:"correct_answer,omitempty"` // Only for host
 }
 
@@ -25,16 +28,14 @@ func (q Question) ToDTO(isHost bool) QuestionDTO {
         }
     }
     
-    timeLimit := q.TimeLimit
-    if timeLimit <= 0 {
-        timeLimit = 30 // Default 30 seconds if not set
-    }
-    
     dto := QuestionDTO{
         ID:        q.ID,
+        Type:      q.QuestionKind(),
         Text:      q.Text,
         Options:   optionDTOs,
-        TimeLimit: timeLimit,
+        Tolerance: q.Tolerance,
+        Weight:    q.EffectiveWeight(),
+        TimeLimit: q.EffectiveTimeLimit(),
     }
     if isHost {
         dto.CorrectAnswer = q.CorrectAnswer
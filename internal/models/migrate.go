@@ -0,0 +1,191 @@
+// backend/internal/models/migrate.go
+package models
+
+import (
+    "fmt"
+    "log"
+    "time"
+
+    "gorm.io/gorm"
+)
+
+// Migrate brings the database schema up to date with the models.
+func Migrate(db *gorm.DB) error {
+    // Team names were unique per session even among deleted teams; the index
+    // that replaces it only covers the live ones.
+    if db.Migrator().HasIndex(&Team{}, "idx_team_session_name") {
+        log.Printf("Dropping index idx_team_session_name")
+        if err := db.Migrator().DropIndex(&Team{}, "idx_team_session_name"); err != nil {
+            return err
+        }
+    }
+
+    // Answers, progress and participants recorded before sessions existed
+    // belong to no session. They are moved to a session of their own before
+    // the indexes that are unique per session are created.
+    if err := db.AutoMigrate(&User{}, &Quiz{}, &QuizSession{}); err != nil {
+        return err
+    }
+    if err := migrateLegacySessions(db); err != nil {
+        return err
+    }
+
+    return db.AutoMigrate(
+        &User{},
+        &Quiz{},
+        &Question{},
+        &Option{},
+        &UserQuizResponse{},
+        &UserQuizProgress{},
+        &QuizParticipant{},
+        &QuizSession{},
+        &SessionBan{},
+        &Team{},
+    )
+}
+
+// legacyCondition matches the rows that belong to no session.
+const legacyCondition = "session_id IS NULL OR session_id = 0"
+
+// migrateLegacySessions gives every quiz with rows outside any session a
+// finished "legacy" session holding them, then drops the duplicates the
+// per-session unique indexes would refuse: a player's later answers to a
+// question they already answered, and all but their latest progress.
+func migrateLegacySessions(db *gorm.DB) error {
+    var legacy []interface{}
+    for _, model := range []interface{}{&UserQuizResponse{}, &UserQuizProgress{}, &QuizParticipant{}} {
+        if !db.Migrator().HasTable(model) {
+            continue
+        }
+        if !db.Migrator().HasColumn(model, "SessionID") {
+            if err := db.Migrator().AddColumn(model, "SessionID"); err != nil {
+                return err
+            }
+        }
+        legacy = append(legacy, model)
+    }
+    if len(legacy) == 0 {
+        return nil
+    }
+
+    quizIDs := map[uint]bool{}
+    for _, model := range legacy {
+        var ids []uint
+        if err := db.Unscoped().Model(model).Where(legacyCondition).Distinct().Pluck("quiz_id", &ids).Error; err != nil {
+            return err
+        }
+        for _, id := range ids {
+            quizIDs[id] = true
+        }
+    }
+
+    for quizID := range quizIDs {
+        session, err := legacySession(db, quizID)
+        if err != nil {
+            return err
+        }
+        log.Printf("Moving the answers of quiz %d from before sessions to session %s", quizID, session.JoinCode)
+        for _, model := range legacy {
+            err := db.Unscoped().Model(model).
+                Where("quiz_id = ?", quizID).
+                Where(legacyCondition).
+                UpdateColumn("session_id", session.ID).Error
+            if err != nil {
+                return err
+            }
+        }
+    }
+
+    if db.Migrator().HasTable(&UserQuizResponse{}) {
+        err := db.Exec(`DELETE FROM user_quiz_responses WHERE id NOT IN (
+            SELECT MIN(id) FROM user_quiz_responses GROUP BY session_id, user_id, question_id
+        )`).Error
+        if err != nil {
+            return err
+        }
+    }
+    if db.Migrator().HasTable(&UserQuizProgress{}) {
+        err := db.Exec(`DELETE FROM user_quiz_progress WHERE id NOT IN (
+            SELECT MAX(id) FROM user_quiz_progress GROUP BY session_id, user_id
+        )`).Error
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// legacySession returns the session that holds a quiz's rows from before
+// sessions, creating it as a finished session spanning those rows.
+func legacySession(db *gorm.DB, quizID uint) (*QuizSession, error) {
+    joinCode := fmt.Sprintf("legacy-%d", quizID)
+    var session QuizSession
+    err := db.Unscoped().Where("join_code = ?", joinCode).First(&session).Error
+    if err == nil {
+        return &session, nil
+    }
+    if err != gorm.ErrRecordNotFound {
+        return nil, err
+    }
+
+    // The quiz may have been deleted since; its history is kept all the same.
+    var quiz Quiz
+    if err := db.Unscoped().First(&quiz, quizID).Error; err != nil && err != gorm.ErrRecordNotFound {
+        return nil, err
+    }
+    first, last, err := legacySpan(db, quizID)
+    if err != nil {
+        return nil, err
+    }
+    session = QuizSession{
+        CreatedAt:   first,
+        QuizID:      quizID,
+        HostID:      quiz.CreatorID,
+        JoinCode:    joinCode,
+        State:       SessionFinished,
+        Scoring:     quiz.Scoring,
+        Pacing:      quiz.Pacing,
+        TeamMode:    TeamsNone,
+        TeamScoring: TeamScoreSum,
+        StartedAt:   &first,
+        EndedAt:     &last,
+    }
+    if session.Pacing == "" {
+        session.Pacing = PacingHost
+    }
+    if err := db.Omit("Participants", "Teams").Create(&session).Error; err != nil {
+        return nil, err
+    }
+    return &session, nil
+}
+
+// legacySpan returns when a quiz's rows from before sessions were first
+// created and last updated.
+func legacySpan(db *gorm.DB, quizID uint) (first, last time.Time, err error) {
+    first = time.Now()
+    for _, model := range []interface{}{&UserQuizResponse{}, &UserQuizProgress{}} {
+        if !db.Migrator().HasTable(model) {
+            continue
+        }
+        rows := func() *gorm.DB {
+            return db.Unscoped().Model(model).Where("quiz_id = ?", quizID).Where(legacyCondition).Limit(1)
+        }
+        var created, updated []time.Time
+        if err := rows().Order("created_at").Pluck("created_at", &created).Error; err != nil {
+            return first, last, err
+        }
+        if err := rows().Order("updated_at DESC").Pluck("updated_at", &updated).Error; err != nil {
+            return first, last, err
+        }
+        if len(created) > 0 && created[0].Before(first) {
+            first = created[0]
+        }
+        if len(updated) > 0 && updated[0].After(last) {
+            last = updated[0]
+        }
+    }
+    if last.Before(first) {
+        last = first
+    }
+    return first, last, nil
+}
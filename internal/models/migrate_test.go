@@ -0,0 +1,117 @@
+// backend/internal/models/migrate_test.go
+package models
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The tables as they were before sessions. Answers already had a session_id
+// column that was left at 0; progress and participants had none.
+type legacyResponse struct {
+	ID         uint
+	UserID     uint
+	QuizID     uint
+	SessionID  uint
+	QuestionID uint
+	Answer     string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (legacyResponse) TableName() string { return "user_quiz_responses" }
+
+type legacyProgress struct {
+	ID        uint
+	UserID    uint
+	QuizID    uint
+	NextIndex int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (legacyProgress) TableName() string { return "user_quiz_progress" }
+
+type legacyParticipant struct {
+	ID     uint
+	QuizID uint
+	UserID uint
+}
+
+func (legacyParticipant) TableName() string { return "quiz_participants" }
+
+func TestMigrateMovesLegacyRowsToASession(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&Quiz{}, &legacyResponse{}, &legacyProgress{}, &legacyParticipant{}); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&[]Quiz{
+		{ID: 1, Title: "One", CreatorID: 7, QuizCode: "AAAAAA"},
+		{ID: 2, Title: "Two", CreatorID: 8, QuizCode: "BBBBBB"},
+	})
+	db.Create(&[]legacyResponse{
+		{UserID: 1, QuizID: 1, QuestionID: 10, Answer: "first"},
+		{UserID: 1, QuizID: 1, QuestionID: 10, Answer: "again"},
+		{UserID: 1, QuizID: 1, QuestionID: 11, Answer: "b"},
+		{UserID: 2, QuizID: 1, QuestionID: 10, Answer: "c"},
+		{UserID: 1, QuizID: 2, QuestionID: 20, Answer: "d"},
+	})
+	db.Create(&[]legacyProgress{
+		{UserID: 1, QuizID: 1, NextIndex: 1},
+		{UserID: 1, QuizID: 1, NextIndex: 2},
+		{UserID: 2, QuizID: 1, NextIndex: 1},
+	})
+	db.Create(&[]legacyParticipant{{QuizID: 1, UserID: 1}, {QuizID: 1, UserID: 2}})
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Migrate() = %v", err)
+	}
+	// Running it again finds nothing left to move.
+	if err := Migrate(db); err != nil {
+		t.Fatalf("second Migrate() = %v", err)
+	}
+
+	var sessions []QuizSession
+	db.Order("quiz_id").Find(&sessions)
+	if len(sessions) != 2 {
+		t.Fatalf("got %d sessions, want one per quiz", len(sessions))
+	}
+	session := sessions[0]
+	if session.QuizID != 1 || session.HostID != 7 || session.State != SessionFinished || session.JoinCode != "legacy-1" {
+		t.Errorf("legacy session = %+v", session)
+	}
+
+	var responses []UserQuizResponse
+	db.Where("session_id = ?", session.ID).Order("id").Find(&responses)
+	if len(responses) != 3 {
+		t.Fatalf("got %d answers in the legacy session, want 3", len(responses))
+	}
+	if responses[0].Answer != "first" {
+		t.Errorf("kept answer %q of the duplicates, want the first", responses[0].Answer)
+	}
+
+	var progress []UserQuizProgress
+	db.Where("session_id = ?", session.ID).Order("user_id").Find(&progress)
+	if len(progress) != 2 || progress[0].NextIndex != 2 {
+		t.Errorf("progress = %+v, want the latest row of each player", progress)
+	}
+
+	var unassigned int64
+	db.Model(&QuizParticipant{}).Where(legacyCondition).Count(&unassigned)
+	if unassigned != 0 {
+		t.Errorf("%d participants are left without a session", unassigned)
+	}
+
+	// The unique indexes are in place.
+	duplicate := UserQuizResponse{UserID: 1, SessionID: session.ID, QuestionID: 10}
+	if err := db.Create(&duplicate).Error; err == nil {
+		t.Error("a second answer to the same question was accepted")
+	}
+}
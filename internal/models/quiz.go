@@ -20,38 +20,102 @@ type Quiz struct {
     TimeLimit   uint      `json:"time_limit"`
     QuizCode    string    `json:"quiz_code" gorm:"unique"`
     IsActive    bool      `json:"is_active" gorm:"default:false"`
+    Scoring     ScoringConfig `json:"scoring" gorm:"embedded;embeddedPrefix:scoring_"`
+    Pacing      PacingMode `json:"pacing" gorm:"not null;default:'host'"`
+    SelfPacedLimit uint    `json:"self_paced_limit"` // Seconds for the whole quiz when self-paced; 0 for no limit
     Questions   []Question `json:"questions,omitempty" gorm:"foreignKey:QuizID"`
 }
 
+// QuestionType decides how a question is rendered and how its answers are graded.
+// The answer and CorrectAnswer formats are:
+//   - single_choice: the text of one option
+//   - true_false: "true" or "false"
+//   - multi_select: a JSON array of the texts of the correct options
+//   - numeric: a number; answers within Tolerance of it are correct
+//   - ordering: a JSON array of option texts in the correct sequence
+//   - short_text: an accepted answer, or a JSON array of accepted answers,
+//     compared ignoring case, extra whitespace and accents
+type QuestionType string
+
+const (
+    QuestionSingleChoice QuestionType = "single_choice"
+    QuestionTrueFalse    QuestionType = "true_false"
+    QuestionMultiSelect  QuestionType = "multi_select"
+    QuestionNumeric      QuestionType = "numeric"
+    QuestionOrdering     QuestionType = "ordering"
+    QuestionShortText    QuestionType = "short_text"
+)
+
 type Question struct {
     ID            uint      `json:"id" gorm:"primaryKey"`
     CreatedAt     time.Time `json:"created_at"`
     UpdatedAt     time.Time `json:"updated_at"`
     DeletedAt     gorm.DeletedAt `json:"deleted_at" gorm:"index"`
     QuizID        uint      `json:"quiz_id"`
+    Position      int       `json:"position" gorm:"not null;default:0"`
+    Type          QuestionType `json:"type" gorm:"not null;default:'single_choice'"`
     Text          string    `json:"text" gorm:"not null"`
     Options       []Option  `json:"options,omitempty" gorm:"foreignKey:QuestionID"`
     CorrectAnswer string    `json:"correct_answer" gorm:"not null"`
+    Tolerance     float64   `json:"tolerance"` // Numeric questions only
+    Weight        float64   `json:"weight" gorm:"not null;default:1"`
     TimeLimit     int       `json:"time_limit"`
 }
 
+// QuestionKind returns the question type, treating an unset type as single choice.
+func (q Question) QuestionKind() QuestionType {
+    if q.Type == "" {
+        return QuestionSingleChoice
+    }
+    return q.Type
+}
+
+// EffectiveWeight is the multiplier applied to the question's points; an unset
+// weight counts once.
+func (q Question) EffectiveWeight() float64 {
+    if q.Weight <= 0 {
+        return 1
+    }
+    return q.Weight
+}
+
+// DefaultQuestionTimeLimit is used when a question has no time limit set.
+const DefaultQuestionTimeLimit = 30
+
+// EffectiveTimeLimit returns the question time limit in seconds, falling back
+// to DefaultQuestionTimeLimit when none is set.
+func (q Question) EffectiveTimeLimit() int {
+    if q.TimeLimit <= 0 {
+        return DefaultQuestionTimeLimit
+    }
+    return q.TimeLimit
+}
+
 type Option struct {
     ID          uint      `json:"id" gorm:"primaryKey"`
     CreatedAt   time.Time `json:"created_at"`
     UpdatedAt   time.Time `json:"updated_at"`
     DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
     QuestionID  uint      `json:"question_id"`
+    Position    int       `json:"position" gorm:"not null;default:0"`
     Text        string    `json:"text" gorm:"not null"`
 }
 
+// UserQuizResponse is a player's answer to one question of a session. A player
+// answers each question of a session at most once.
 type UserQuizResponse struct {
     ID          uint      `json:"id" gorm:"primaryKey"`
     CreatedAt   time.Time `json:"created_at"`
     UpdatedAt   time.Time `json:"updated_at"`
     DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
-    UserID      uint      `json:"user_id"`
+    UserID      uint      `json:"user_id" gorm:"uniqueIndex:idx_response_once,priority:2;uniqueIndex:idx_response_idempotency,priority:2"`
     QuizID      uint      `json:"quiz_id"`
-    QuestionID  uint      `json:"question_id"`
+    SessionID   uint      `json:"session_id" gorm:"index;uniqueIndex:idx_response_once,priority:1;uniqueIndex:idx_response_idempotency,priority:1"`
+    QuestionID  uint      `json:"question_id" gorm:"uniqueIndex:idx_response_once,priority:3"`
+    // IdempotencyKey identifies the request that submitted the answer, so a
+    // retried request gets the original result back. A key is used once per
+    // player and session; answers without one are not constrained.
+    IdempotencyKey string `json:"idempotency_key,omitempty" gorm:"uniqueIndex:idx_response_idempotency,priority:3,where:idempotency_key <> ''"`
     Answer      string    `json:"answer"`
     Score       int       `json:"score"`
     TimeSpent   int       `json:"time_spent"`
@@ -69,21 +133,34 @@ type QuizParticipant struct {
     UpdatedAt   time.Time `json:"updated_at"`
     DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
     QuizID      uint      `json:"quiz_id"`
+    SessionID   uint      `json:"session_id" gorm:"index"`
     UserID      uint      `json:"user_id"`
+    TeamID      *uint     `json:"team_id" gorm:"index"` // nil until the player is in a team
 }
 
 // models/quiz.go
 type LeaderboardEntry struct {
+    UserID      uint   `json:"userId"`
     Username    string `json:"username"`
     TotalScore int    `json:"score"` // Changed to TotalScore to match the SQL query
+    TeamID      *uint  `json:"teamId,omitempty"`
+    TeamName    string `json:"team,omitempty"`
 }
 
 
 type UserQuizProgress struct {
     ID        uint      `gorm:"primaryKey"`
-    UserID    uint      `gorm:"not null"`
+    UserID    uint      `gorm:"not null;uniqueIndex:idx_progress_user_session,priority:2"`
     QuizID    uint      `gorm:"not null"`
+    SessionID uint      `gorm:"index;uniqueIndex:idx_progress_user_session,priority:1"`
     NextIndex int       `gorm:"not null"` // The index of the next question to serve
+    Streak    int       `gorm:"not null;default:0"` // Fully correct answers in a row
+    // Survival games only: whether the player is out, the index of the
+    // question that knocked them out and of the revive round that last
+    // brought them back.
+    Eliminated   bool   `gorm:"not null;default:false"`
+    EliminatedOn *int
+    RevivedOn    *int
     CreatedAt time.Time
     UpdatedAt time.Time
 }
@@ -0,0 +1,47 @@
+// backend/internal/models/scoring.go
+package models
+
+// ScoringStrategyName selects how the points of a correct answer depend on the
+// time taken to give it.
+type ScoringStrategyName string
+
+const (
+    // ScoringClassic deducts one percent of the base points per second.
+    ScoringClassic ScoringStrategyName = "classic"
+    // ScoringSpeed decays from full points for an instant answer to half
+    // points at the question's time limit.
+    ScoringSpeed ScoringStrategyName = "speed"
+    // ScoringFlat awards the base points however long the answer took.
+    ScoringFlat ScoringStrategyName = "flat"
+)
+
+// DefaultBasePoints is awarded for a fully correct answer when a quiz does not
+// set its own base points.
+const DefaultBasePoints = 1000
+
+// ScoringConfig is how a quiz scores its answers. It is stored on the quiz and
+// copied onto each session when it is created, so editing the quiz never
+// changes the scoring of a game already played. Zero values mean the defaults:
+// classic scoring out of 1000 points with no streak bonus or penalty.
+type ScoringConfig struct {
+    Strategy            ScoringStrategyName `json:"strategy"`
+    BasePoints          int                 `json:"base_points"`
+    // StreakBonus multiplies the points by 1 + StreakBonus for each correct
+    // answer in a row before this one, up to MaxStreakMultiplier.
+    StreakBonus         float64             `json:"streak_bonus"`
+    MaxStreakMultiplier float64             `json:"max_streak_multiplier"`
+    // WrongPenalty is deducted for a wrong answer (negative marking).
+    // Unanswered questions are never penalised.
+    WrongPenalty        int                 `json:"wrong_penalty"`
+}
+
+// WithDefaults returns the configuration with unset fields filled in.
+func (c ScoringConfig) WithDefaults() ScoringConfig {
+    if c.Strategy == "" {
+        c.Strategy = ScoringClassic
+    }
+    if c.BasePoints == 0 {
+        c.BasePoints = DefaultBasePoints
+    }
+    return c
+}
@@ -0,0 +1,118 @@
+// backend/internal/models/session.go
+package models
+
+import (
+    "time"
+    "gorm.io/gorm"
+)
+
+// SessionState is where a quiz session is in its lifecycle:
+// draft -> lobby -> in_progress <-> paused -> finished -> archived.
+type SessionState string
+
+const (
+    SessionDraft      SessionState = "draft"       // created, not yet open to players
+    SessionLobby      SessionState = "lobby"       // players can join, questions not started
+    SessionInProgress SessionState = "in_progress" // questions are being played
+    SessionPaused     SessionState = "paused"      // host froze the game
+    SessionFinished   SessionState = "finished"    // results are final
+    SessionArchived   SessionState = "archived"    // kept for history only
+)
+
+// sessionTransitions lists the states each state may move to.
+var sessionTransitions = map[SessionState][]SessionState{
+    SessionDraft:      {SessionLobby},
+    SessionLobby:      {SessionInProgress, SessionFinished},
+    SessionInProgress: {SessionPaused, SessionFinished},
+    SessionPaused:     {SessionInProgress, SessionFinished},
+    SessionFinished:   {SessionArchived},
+}
+
+// CanTransitionTo reports whether a session may move from s to next.
+func (s SessionState) CanTransitionTo(next SessionState) bool {
+    for _, allowed := range sessionTransitions[s] {
+        if allowed == next {
+            return true
+        }
+    }
+    return false
+}
+
+// IsOpen reports whether the session still accepts players, i.e. it has been
+// opened and has not finished.
+func (s SessionState) IsOpen() bool {
+    return s == SessionLobby || s == SessionInProgress || s == SessionPaused
+}
+
+// PacingMode decides who moves players from one question to the next.
+type PacingMode string
+
+const (
+    // PacingHost shows every player the same question; the host reveals the
+    // results and advances.
+    PacingHost PacingMode = "host"
+    // PacingSelf lets each player move on as soon as they answer, optionally
+    // within an overall time limit.
+    PacingSelf PacingMode = "self"
+)
+
+// QuestionPhase is the step a host-paced session is at on its current question.
+type QuestionPhase string
+
+const (
+    PhaseQuestion QuestionPhase = "question" // players are answering
+    PhaseResults  QuestionPhase = "results"  // answers are closed and the results shown
+)
+
+// QuizSession is one run of a quiz. The quiz holds the questions; the session
+// holds everything that happens while playing them, so the same quiz can be
+// run many times and each run keeps its own results.
+type QuizSession struct {
+    ID           uint              `json:"id" gorm:"primaryKey"`
+    CreatedAt    time.Time         `json:"created_at"`
+    UpdatedAt    time.Time         `json:"updated_at"`
+    DeletedAt    gorm.DeletedAt    `json:"deleted_at" gorm:"index"`
+    QuizID       uint              `json:"quiz_id" gorm:"index;not null"`
+    HostID       uint              `json:"host_id" gorm:"not null"`
+    JoinCode     string            `json:"join_code" gorm:"uniqueIndex;not null"`
+    State        SessionState      `json:"state" gorm:"not null"`
+    // Shuffling is fixed when the session is created; ShuffleSeed lets the
+    // exact order players saw be reproduced later.
+    ShuffleQuestions bool          `json:"shuffle_questions" gorm:"not null;default:false"`
+    ShuffleOptions   bool          `json:"shuffle_options" gorm:"not null;default:false"`
+    ShuffleSeed      int64         `json:"shuffle_seed"`
+    Scoring      ScoringConfig     `json:"scoring" gorm:"embedded;embeddedPrefix:scoring_"`
+    Pacing       PacingMode        `json:"pacing" gorm:"not null;default:'host'"`
+    // SelfPacedLimit is the number of seconds self-paced players have for the
+    // whole quiz (0 for no limit); Deadline is when that time runs out.
+    SelfPacedLimit uint            `json:"self_paced_limit"`
+    Deadline     *time.Time        `json:"deadline"`
+    // CurrentIndex is the question a host-paced session is on, and Phase
+    // whether its results have been revealed.
+    CurrentIndex int               `json:"current_index" gorm:"not null;default:0"`
+    Phase        QuestionPhase     `json:"phase" gorm:"not null;default:'question'"`
+    // TeamMode is how players are put into teams, and TeamScoring how the
+    // scores of a team's members make up its score.
+    TeamMode     TeamMode          `json:"team_mode" gorm:"not null;default:'none'"`
+    TeamScoring  TeamScoring       `json:"team_scoring" gorm:"not null;default:'sum'"`
+    Survival     SurvivalConfig    `json:"survival" gorm:"embedded;embeddedPrefix:survival_"`
+    StartedAt    *time.Time        `json:"started_at"`
+    EndedAt      *time.Time        `json:"ended_at"`
+    Participants []QuizParticipant `json:"participants,omitempty" gorm:"foreignKey:SessionID"`
+    Teams        []Team            `json:"teams,omitempty" gorm:"foreignKey:SessionID"`
+}
+
+// SessionBan keeps a user out of a session after the host banned them.
+type SessionBan struct {
+    ID        uint      `json:"id" gorm:"primaryKey"`
+    CreatedAt time.Time `json:"created_at"`
+    SessionID uint      `json:"session_id" gorm:"uniqueIndex:idx_ban_session_user"`
+    UserID    uint      `json:"user_id" gorm:"uniqueIndex:idx_ban_session_user"`
+    BannedBy  uint      `json:"banned_by"`
+    Reason    string    `json:"reason"`
+}
+
+// IsSelfPaced reports whether players of the session move on by themselves.
+func (s QuizSession) IsSelfPaced() bool {
+    return s.Pacing == PacingSelf
+}
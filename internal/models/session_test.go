@@ -0,0 +1,27 @@
+// backend/internal/models/session_test.go
+package models
+
+import "testing"
+
+func TestSessionStateCanTransitionTo(t *testing.T) {
+	states := []SessionState{SessionDraft, SessionLobby, SessionInProgress, SessionPaused, SessionFinished, SessionArchived}
+	allowed := map[[2]SessionState]bool{
+		{SessionDraft, SessionLobby}:         true,
+		{SessionLobby, SessionInProgress}:    true,
+		{SessionLobby, SessionFinished}:      true,
+		{SessionInProgress, SessionPaused}:   true,
+		{SessionInProgress, SessionFinished}: true,
+		{SessionPaused, SessionInProgress}:   true,
+		{SessionPaused, SessionFinished}:     true,
+		{SessionFinished, SessionArchived}:   true,
+	}
+
+	for _, from := range states {
+		for _, to := range states {
+			want := allowed[[2]SessionState{from, to}]
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%s.CanTransitionTo(%s) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
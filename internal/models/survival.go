@@ -0,0 +1,22 @@
+// backend/internal/models/survival.go
+package models
+
+// SurvivalConfig turns a host-paced session into an elimination game: a
+// player who answers a question wrong, or not fully right, or too slowly is
+// out and follows the rest of the game without answering. The game ends when
+// one player is left or the questions run out. Zero values mean a normal game.
+type SurvivalConfig struct {
+    Enabled     bool `json:"enabled"`
+    // AnswerTime is how many seconds a player has to answer correctly to stay
+    // in; 0 gives them the question's whole time limit.
+    AnswerTime  uint `json:"answer_time"`
+    // ReviveEvery makes every ReviveEvery-th question a revive round, which
+    // players who are out can answer too; those who get it right are back
+    // in. 0 means no revive rounds.
+    ReviveEvery int  `json:"revive_every"`
+}
+
+// IsReviveRound reports whether the question at index is a revive round.
+func (c SurvivalConfig) IsReviveRound(index int) bool {
+    return c.Enabled && c.ReviveEvery > 0 && (index+1)%c.ReviveEvery == 0
+}
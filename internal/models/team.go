@@ -0,0 +1,62 @@
+// backend/internal/models/team.go
+package models
+
+import (
+    "time"
+    "gorm.io/gorm"
+)
+
+// TeamMode decides how the players of a session are put into teams.
+type TeamMode string
+
+const (
+    // TeamsNone plays every player for themselves.
+    TeamsNone TeamMode = "none"
+    // TeamsAssigned lets only the host put players in teams.
+    TeamsAssigned TeamMode = "assigned"
+    // TeamsSelfSelect lets players pick their own team in the lobby.
+    TeamsSelfSelect TeamMode = "self_select"
+    // TeamsAuto puts each player who joins in the smallest team.
+    TeamsAuto TeamMode = "auto"
+)
+
+// TeamScoring is how the scores of a team's members add up to the team score.
+type TeamScoring string
+
+const (
+    TeamScoreSum     TeamScoring = "sum"     // the members' scores added up
+    TeamScoreAverage TeamScoring = "average" // the mean over all members, rounded
+    TeamScoreBest    TeamScoring = "best"    // the best member's score
+)
+
+// Team is a team of players in one session.
+type Team struct {
+    ID        uint           `json:"id" gorm:"primaryKey"`
+    CreatedAt time.Time      `json:"created_at"`
+    UpdatedAt time.Time      `json:"updated_at"`
+    DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+    SessionID uint           `json:"session_id" gorm:"uniqueIndex:idx_team_session_live_name,where:deleted_at IS NULL"`
+    Name      string         `json:"name" gorm:"not null;uniqueIndex:idx_team_session_live_name,where:deleted_at IS NULL"`
+}
+
+// TeamMember is a player of a session and the team they are in, if any.
+type TeamMember struct {
+    UserID   uint   `json:"userId"`
+    Username string `json:"username"`
+    TeamID   *uint  `json:"teamId"`
+}
+
+// TeamLeaderboardEntry is a team's place on the team leaderboard. Tied teams
+// share a rank.
+type TeamLeaderboardEntry struct {
+    TeamID  uint   `json:"teamId"`
+    Name    string `json:"name"`
+    Rank    int    `json:"rank"`
+    Score   int    `json:"score"`
+    Members int    `json:"members"`
+}
+
+// HasTeams reports whether the session's players play in teams.
+func (s QuizSession) HasTeams() bool {
+    return s.TeamMode != "" && s.TeamMode != TeamsNone
+}
@@ -0,0 +1,509 @@
+// backend/internal/quiz/authoring.go
+package quiz
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"quiz-system/internal/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// editableQuiz authorizes the creator and makes sure no session is playing the
+// quiz, since changing questions mid-game would shift every player's index.
+func (s *Service) editableQuiz(quizCode string, userID uint) (*models.Quiz, error) {
+	quiz, err := s.authorizeCreator(quizCode, userID)
+	if err != nil {
+		return nil, err
+	}
+	live, err := s.repo.HasLiveSession(quiz.ID)
+	if err != nil {
+		return nil, err
+	}
+	if live {
+		return nil, ErrQuizInUse
+	}
+	return quiz, nil
+}
+
+// editableQuestion loads a question and checks it belongs to the quiz.
+func (s *Service) editableQuestion(quizCode string, userID uint, questionID uint) (*models.Quiz, *models.Question, error) {
+	quiz, err := s.editableQuiz(quizCode, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	question, err := s.repo.GetQuestion(questionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if question.QuizID != quiz.ID {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+	return quiz, question, nil
+}
+
+// editableOption loads an option and checks it belongs to the question.
+func (s *Service) editableOption(quizCode string, userID uint, questionID, optionID uint) (*models.Quiz, *models.Question, *models.Option, error) {
+	quiz, question, err := s.editableQuestion(quizCode, userID, questionID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	option, err := s.repo.GetOption(optionID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if option.QuestionID != question.ID {
+		return nil, nil, nil, gorm.ErrRecordNotFound
+	}
+	return quiz, question, option, nil
+}
+
+// refreshQuiz drops the cached quiz and returns the current copy from the database.
+func (s *Service) refreshQuiz(quizCode string) (*models.Quiz, error) {
+	if err := s.cache.DeleteQuiz(quizCode); err != nil {
+		log.Printf("Error invalidating cached quiz %s: %v", quizCode, err)
+	}
+	return s.GetQuizByCode(quizCode)
+}
+
+func (s *Service) UpdateQuiz(quizCode string, userID uint, update QuizUpdate) (*models.Quiz, error) {
+	quiz, err := s.editableQuiz(quizCode, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	if update.Title != nil {
+		if strings.TrimSpace(*update.Title) == "" {
+			return nil, fmt.Errorf("%w: title is required", ErrInvalidInput)
+		}
+		fields["title"] = *update.Title
+	}
+	if update.Description != nil {
+		fields["description"] = *update.Description
+	}
+	if update.TimeLimit != nil {
+		fields["time_limit"] = *update.TimeLimit
+	}
+	if update.Scoring != nil {
+		if err := validateScoring(*update.Scoring); err != nil {
+			return nil, err
+		}
+		fields["scoring_strategy"] = update.Scoring.Strategy
+		fields["scoring_base_points"] = update.Scoring.BasePoints
+		fields["scoring_streak_bonus"] = update.Scoring.StreakBonus
+		fields["scoring_max_streak_multiplier"] = update.Scoring.MaxStreakMultiplier
+		fields["scoring_wrong_penalty"] = update.Scoring.WrongPenalty
+	}
+	if update.Pacing != nil {
+		if err := validatePacing(*update.Pacing); err != nil {
+			return nil, err
+		}
+		fields["pacing"] = *update.Pacing
+	}
+	if update.SelfPacedLimit != nil {
+		fields["self_paced_limit"] = *update.SelfPacedLimit
+	}
+	if len(fields) > 0 {
+		if err := s.repo.UpdateQuizFields(quiz.ID, fields); err != nil {
+			return nil, err
+		}
+	}
+	return s.refreshQuiz(quizCode)
+}
+
+func (s *Service) DeleteQuiz(quizCode string, userID uint) error {
+	quiz, err := s.editableQuiz(quizCode, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteQuiz(quiz.ID); err != nil {
+		return err
+	}
+	if err := s.cache.DeleteQuiz(quizCode); err != nil {
+		log.Printf("Error invalidating cached quiz %s: %v", quizCode, err)
+	}
+	log.Printf("Deleted quiz %s", quizCode)
+	return nil
+}
+
+// DuplicateQuiz copies a quiz with its questions and options under a new code.
+func (s *Service) DuplicateQuiz(quizCode string, userID uint) (*models.Quiz, error) {
+	original, err := s.authorizeCreator(quizCode, userID)
+	if err != nil {
+		return nil, err
+	}
+	questions, err := s.repo.GetQuizQuestions(original.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicate := &models.Quiz{
+		Title:          original.Title + " (copy)",
+		Description:    original.Description,
+		CreatorID:      userID,
+		TimeLimit:      original.TimeLimit,
+		Scoring:        original.Scoring,
+		Pacing:         original.Pacing,
+		SelfPacedLimit: original.SelfPacedLimit,
+		Questions:      make([]models.Question, len(questions)),
+	}
+	for i, question := range questions {
+		options := make([]models.Option, len(question.Options))
+		for j, option := range question.Options {
+			options[j] = models.Option{Text: option.Text, Position: option.Position}
+		}
+		duplicate.Questions[i] = models.Question{
+			Position:      question.Position,
+			Type:          question.Type,
+			Text:          question.Text,
+			Options:       options,
+			CorrectAnswer: question.CorrectAnswer,
+			Tolerance:     question.Tolerance,
+			Weight:        question.Weight,
+			TimeLimit:     question.TimeLimit,
+		}
+	}
+
+	if err := s.CreateQuiz(duplicate); err != nil {
+		return nil, err
+	}
+	return duplicate, nil
+}
+
+// AddQuestion appends a question, with its options, to the end of the quiz.
+func (s *Service) AddQuestion(quizCode string, userID uint, question *models.Question) (*models.Question, error) {
+	quiz, err := s.editableQuiz(quizCode, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateQuestion(question); err != nil {
+		return nil, err
+	}
+
+	position, err := s.repo.NextQuestionPosition(quiz.ID)
+	if err != nil {
+		return nil, err
+	}
+	question.ID = 0
+	question.QuizID = quiz.ID
+	question.Position = position
+	for i := range question.Options {
+		question.Options[i].ID = 0
+		question.Options[i].Position = i
+	}
+
+	if err := s.repo.CreateQuestion(question); err != nil {
+		return nil, err
+	}
+	s.refreshQuiz(quizCode)
+	return question, nil
+}
+
+func (s *Service) UpdateQuestion(quizCode string, userID uint, questionID uint, update QuestionUpdate) (*models.Question, error) {
+	_, question, err := s.editableQuestion(quizCode, userID, questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the question as it will be once the update is applied.
+	updated := *question
+	fields := map[string]interface{}{}
+	if update.Type != nil {
+		updated.Type = *update.Type
+		fields["type"] = *update.Type
+	}
+	if update.Text != nil {
+		updated.Text = *update.Text
+		fields["text"] = *update.Text
+	}
+	if update.CorrectAnswer != nil {
+		updated.CorrectAnswer = *update.CorrectAnswer
+		fields["correct_answer"] = *update.CorrectAnswer
+	}
+	if update.Tolerance != nil {
+		updated.Tolerance = *update.Tolerance
+		fields["tolerance"] = *update.Tolerance
+	}
+	if update.Weight != nil {
+		updated.Weight = *update.Weight
+		fields["weight"] = *update.Weight
+	}
+	if update.TimeLimit != nil {
+		fields["time_limit"] = *update.TimeLimit
+	}
+	if err := validateQuestion(&updated); err != nil {
+		return nil, err
+	}
+	if len(fields) > 0 {
+		if err := s.repo.UpdateQuestionFields(question.ID, fields); err != nil {
+			return nil, err
+		}
+	}
+	s.refreshQuiz(quizCode)
+	return s.repo.GetQuestion(question.ID)
+}
+
+func (s *Service) DeleteQuestion(quizCode string, userID uint, questionID uint) error {
+	quiz, question, err := s.editableQuestion(quizCode, userID, questionID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteQuestion(question.ID); err != nil {
+		return err
+	}
+
+	// Close the gap left in the ordering.
+	remaining, err := s.repo.GetQuizQuestions(quiz.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.SetQuestionPositions(quiz.ID, questionIDs(remaining)); err != nil {
+		return err
+	}
+	s.refreshQuiz(quizCode)
+	return nil
+}
+
+// ReorderQuestions sets the order of the quiz's questions. ids must list every
+// question of the quiz exactly once.
+func (s *Service) ReorderQuestions(quizCode string, userID uint, ids []uint) (*models.Quiz, error) {
+	quiz, err := s.editableQuiz(quizCode, userID)
+	if err != nil {
+		return nil, err
+	}
+	questions, err := s.repo.GetQuizQuestions(quiz.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !samePermutation(questionIDs(questions), ids) {
+		return nil, fmt.Errorf("%w: order must list every question of the quiz exactly once", ErrInvalidInput)
+	}
+	if err := s.repo.SetQuestionPositions(quiz.ID, ids); err != nil {
+		return nil, err
+	}
+	return s.refreshQuiz(quizCode)
+}
+
+// AddOption appends an option to the end of a question. On an ordering
+// question the new option also goes last in the correct order.
+func (s *Service) AddOption(quizCode string, userID uint, questionID uint, option *models.Option) (*models.Option, error) {
+	_, question, err := s.editableQuestion(quizCode, userID, questionID)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(option.Text) == "" {
+		return nil, fmt.Errorf("%w: option text is required", ErrInvalidInput)
+	}
+
+	updated, err := s.repo.EditOptions(question.ID, func(question *models.Question) error {
+		question.Options = append(question.Options, models.Option{Text: option.Text})
+		if question.QuestionKind() == models.QuestionOrdering {
+			answer, err := appendToAnswer(question.CorrectAnswer, option.Text)
+			if err != nil {
+				return err
+			}
+			question.CorrectAnswer = answer
+		}
+		return validateQuestion(question)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.refreshQuiz(quizCode)
+	return &updated.Options[len(updated.Options)-1], nil
+}
+
+// UpdateOption changes an option's text. The question's correct answer is
+// stored as option text, so it follows the rename.
+func (s *Service) UpdateOption(quizCode string, userID uint, questionID, optionID uint, update OptionUpdate) (*models.Option, error) {
+	_, question, option, err := s.editableOption(quizCode, userID, questionID, optionID)
+	if err != nil {
+		return nil, err
+	}
+	if update.Text != nil {
+		if strings.TrimSpace(*update.Text) == "" {
+			return nil, fmt.Errorf("%w: option text is required", ErrInvalidInput)
+		}
+		_, err := s.repo.EditOptions(question.ID, func(question *models.Question) error {
+			i := optionIndex(question, option.ID)
+			if i < 0 {
+				return gorm.ErrRecordNotFound
+			}
+			old := question.Options[i].Text
+			question.Options[i].Text = *update.Text
+			if answer, changed := renameInAnswer(question, old, update.Text); changed {
+				question.CorrectAnswer = answer
+			}
+			return validateQuestion(question)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.refreshQuiz(quizCode)
+	return s.repo.GetOption(option.ID)
+}
+
+// DeleteOption removes an option from a question and from its correct answer.
+// The option that is the whole correct answer of a single choice or true/false
+// question cannot be deleted until another correct answer is set.
+func (s *Service) DeleteOption(quizCode string, userID uint, questionID, optionID uint) error {
+	_, question, option, err := s.editableOption(quizCode, userID, questionID, optionID)
+	if err != nil {
+		return err
+	}
+	_, err = s.repo.EditOptions(question.ID, func(question *models.Question) error {
+		i := optionIndex(question, option.ID)
+		if i < 0 {
+			return gorm.ErrRecordNotFound
+		}
+		old := question.Options[i].Text
+		if isSoleAnswer(question, old) {
+			return fmt.Errorf("%w: %q is the correct answer; set another correct answer before deleting it", ErrInvalidInput, old)
+		}
+		question.Options = append(question.Options[:i], question.Options[i+1:]...)
+		if answer, changed := renameInAnswer(question, old, nil); changed {
+			question.CorrectAnswer = answer
+		}
+		return validateQuestion(question)
+	})
+	if err != nil {
+		return err
+	}
+	s.refreshQuiz(quizCode)
+	return nil
+}
+
+// ReorderOptions sets the order of a question's options. ids must list every
+// option of the question exactly once.
+func (s *Service) ReorderOptions(quizCode string, userID uint, questionID uint, ids []uint) (*models.Question, error) {
+	_, question, err := s.editableQuestion(quizCode, userID, questionID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.repo.EditOptions(question.ID, func(question *models.Question) error {
+		if !samePermutation(optionIDs(question.Options), ids) {
+			return fmt.Errorf("%w: order must list every option of the question exactly once", ErrInvalidInput)
+		}
+		reordered := make([]models.Option, len(ids))
+		for i, id := range ids {
+			reordered[i] = question.Options[optionIndex(question, id)]
+		}
+		question.Options = reordered
+		return validateQuestion(question)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.refreshQuiz(quizCode)
+	return s.repo.GetQuestion(question.ID)
+}
+
+// renameInAnswer rewrites the question's correct answer after one of its
+// options is renamed to text, or removed when text is nil.
+func renameInAnswer(question *models.Question, old string, text *string) (string, bool) {
+	switch question.QuestionKind() {
+	case models.QuestionMultiSelect, models.QuestionOrdering:
+		list, err := parseAnswerList(question.CorrectAnswer)
+		if err != nil {
+			return "", false
+		}
+		changed := false
+		rewritten := make([]string, 0, len(list))
+		for _, answer := range list {
+			if answer != old {
+				rewritten = append(rewritten, answer)
+				continue
+			}
+			changed = true
+			if text != nil {
+				rewritten = append(rewritten, *text)
+			}
+		}
+		if !changed {
+			return "", false
+		}
+		encoded, err := json.Marshal(rewritten)
+		if err != nil {
+			return "", false
+		}
+		return string(encoded), true
+	case models.QuestionSingleChoice:
+		if text != nil && question.CorrectAnswer == old {
+			return *text, true
+		}
+	}
+	return "", false
+}
+
+// appendToAnswer adds text to the end of a JSON list answer.
+func appendToAnswer(answer string, text string) (string, error) {
+	list, err := parseAnswerList(answer)
+	if err != nil {
+		return "", fmt.Errorf("%w: correct answer must be a JSON array of option texts", ErrInvalidInput)
+	}
+	encoded, err := json.Marshal(append(list, text))
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// isSoleAnswer reports whether the option text is the single correct answer
+// of the question, so the question would have none without it.
+func isSoleAnswer(question *models.Question, text string) bool {
+	switch question.QuestionKind() {
+	case models.QuestionSingleChoice:
+		return question.CorrectAnswer == text
+	case models.QuestionTrueFalse:
+		return strings.EqualFold(strings.TrimSpace(question.CorrectAnswer), strings.TrimSpace(text))
+	}
+	return false
+}
+
+func optionIndex(question *models.Question, optionID uint) int {
+	for i, option := range question.Options {
+		if option.ID == optionID {
+			return i
+		}
+	}
+	return -1
+}
+
+func questionIDs(questions []models.Question) []uint {
+	ids := make([]uint, len(questions))
+	for i, question := range questions {
+		ids[i] = question.ID
+	}
+	return ids
+}
+
+func optionIDs(options []models.Option) []uint {
+	ids := make([]uint, len(options))
+	for i, option := range options {
+		ids[i] = option.ID
+	}
+	return ids
+}
+
+// samePermutation reports whether ids holds exactly the elements of existing.
+func samePermutation(existing, ids []uint) bool {
+	if len(existing) != len(ids) {
+		return false
+	}
+	seen := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		seen[id] = false
+	}
+	for _, id := range ids {
+		used, ok := seen[id]
+		if !ok || used {
+			return false
+		}
+		seen[id] = true
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+// backend/internal/quiz/errors.go
+package quiz
+
+import (
+	"errors"
+	"fmt"
+	"quiz-system/internal/models"
+	"quiz-system/pkg/websocket"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotHost is returned when a user who did not create the quiz tries to
+	// perform a host action on it.
+	ErrNotHost = errors.New("only the quiz host can perform this action")
+
+	// ErrNoActiveQuestion is returned when an answer does not match the
+	// question the server last sent to the player.
+	ErrNoActiveQuestion = errors.New("no active question for this answer")
+
+	// ErrAlreadyAnswered is returned when a player answers a question of the
+	// session a second time.
+	ErrAlreadyAnswered = errors.New("question already answered")
+
+	// ErrAnswerTooLate is returned when an answer arrives after the question's
+	// time limit has expired.
+	ErrAnswerTooLate = errors.New("answer submitted after the time limit")
+
+	// ErrInvalidInput is wrapped by errors describing a malformed request.
+	ErrInvalidInput = errors.New("invalid input")
+
+	// ErrQuizInUse is returned when editing a quiz that is being played.
+	ErrQuizInUse = errors.New("quiz cannot be edited while a session is in progress")
+
+	// ErrWrongPacing is returned for an action the session's pacing mode does
+	// not use, such as the host advancing a self-paced session.
+	ErrWrongPacing = errors.New("action not available in this pacing mode")
+
+	// ErrWrongTeamMode is returned for a team action the session's team mode
+	// does not allow, such as a player picking a team the host assigns.
+	ErrWrongTeamMode = errors.New("action not available in this team mode")
+
+	// ErrEliminated is returned when a player who is out of a survival game
+	// answers a question that is not a revive round.
+	ErrEliminated = errors.New("you have been eliminated")
+
+	// ErrBanned is returned when a user the host banned from a session tries
+	// to join it again.
+	ErrBanned = errors.New("you have been banned from this session")
+
+	// ErrResultsShown is returned when revealing the results of a question
+	// whose results are already shown.
+	ErrResultsShown = errors.New("results of the current question are already shown")
+
+	// ErrNoQuestions is returned when starting a quiz that has no questions.
+	ErrNoQuestions = errors.New("no questions found for quiz")
+
+	// ErrInvalidState is matched by every StateError.
+	ErrInvalidState = errors.New("operation not allowed in the current session state")
+)
+
+// StateError reports an operation the session's current state does not allow.
+type StateError struct {
+	Op    string
+	State models.SessionState
+}
+
+func (e *StateError) Error() string {
+	return fmt.Sprintf("cannot %s: session is %s", e.Op, e.State)
+}
+
+func (e *StateError) Is(target error) bool {
+	return target == ErrInvalidState
+}
+
+// ErrorCode returns the code WebSocket clients are sent for a service error.
+func ErrorCode(err error) websocket.ErrorCode {
+	switch {
+	case errors.Is(err, ErrNotHost):
+		return websocket.ErrorNotHost
+	case errors.Is(err, ErrBanned):
+		return websocket.ErrorBanned
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return websocket.ErrorNotFound
+	case errors.Is(err, ErrInvalidState):
+		return websocket.ErrorInvalidState
+	case errors.Is(err, ErrWrongPacing):
+		return websocket.ErrorWrongPacing
+	case errors.Is(err, ErrNoActiveQuestion):
+		return websocket.ErrorNoActiveQuestion
+	case errors.Is(err, ErrAlreadyAnswered):
+		return websocket.ErrorAlreadyAnswered
+	case errors.Is(err, ErrAnswerTooLate):
+		return websocket.ErrorAnswerTooLate
+	case errors.Is(err, ErrResultsShown):
+		return websocket.ErrorResultsShown
+	case errors.Is(err, ErrNoQuestions):
+		return websocket.ErrorNoQuestions
+	case errors.Is(err, ErrEliminated):
+		return websocket.ErrorEliminated
+	case errors.Is(err, ErrInvalidInput):
+		return websocket.ErrorInvalidData
+	}
+	return websocket.ErrorInternal
+}
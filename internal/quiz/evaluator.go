@@ -0,0 +1,287 @@
+// backend/internal/quiz/evaluator.go
+package quiz
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"quiz-system/internal/models"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// AnswerEvaluator grades the answers to one type of question.
+type AnswerEvaluator interface {
+	// Validate checks that the question is well formed for the type.
+	Validate(question *models.Question) error
+	// Evaluate returns the credit an answer earns, from 0 (wrong) to 1 (fully correct).
+	Evaluate(question *models.Question, answer string) float64
+}
+
+var evaluators = map[models.QuestionType]AnswerEvaluator{
+	models.QuestionSingleChoice: singleChoiceEvaluator{},
+	models.QuestionTrueFalse:    trueFalseEvaluator{},
+	models.QuestionMultiSelect:  multiSelectEvaluator{},
+	models.QuestionNumeric:      numericEvaluator{},
+	models.QuestionOrdering:     orderingEvaluator{},
+	models.QuestionShortText:    shortTextEvaluator{},
+}
+
+func evaluatorFor(question *models.Question) (AnswerEvaluator, error) {
+	evaluator, ok := evaluators[question.QuestionKind()]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown question type %q", ErrInvalidInput, question.Type)
+	}
+	return evaluator, nil
+}
+
+// validateQuestion checks a question before it is saved.
+func validateQuestion(question *models.Question) error {
+	if strings.TrimSpace(question.Text) == "" {
+		return fmt.Errorf("%w: question text is required", ErrInvalidInput)
+	}
+	if question.Weight < 0 {
+		return fmt.Errorf("%w: weight cannot be negative", ErrInvalidInput)
+	}
+	evaluator, err := evaluatorFor(question)
+	if err != nil {
+		return err
+	}
+	return evaluator.Validate(question)
+}
+
+// evaluateAnswer returns the credit an answer earns on a question. Answers to
+// questions of an unknown type earn nothing.
+func evaluateAnswer(question *models.Question, answer string) float64 {
+	evaluator, err := evaluatorFor(question)
+	if err != nil {
+		return 0
+	}
+	return evaluator.Evaluate(question, answer)
+}
+
+type singleChoiceEvaluator struct{}
+
+func (singleChoiceEvaluator) Validate(question *models.Question) error {
+	if len(question.Options) > 0 && !hasOption(question, question.CorrectAnswer) {
+		return fmt.Errorf("%w: correct answer must be one of the options", ErrInvalidInput)
+	}
+	return nil
+}
+
+func (singleChoiceEvaluator) Evaluate(question *models.Question, answer string) float64 {
+	if answer == question.CorrectAnswer {
+		return 1
+	}
+	return 0
+}
+
+type trueFalseEvaluator struct{}
+
+func (trueFalseEvaluator) Validate(question *models.Question) error {
+	if _, err := strconv.ParseBool(strings.TrimSpace(question.CorrectAnswer)); err != nil {
+		return fmt.Errorf("%w: correct answer must be true or false", ErrInvalidInput)
+	}
+	return nil
+}
+
+func (trueFalseEvaluator) Evaluate(question *models.Question, answer string) float64 {
+	want, _ := strconv.ParseBool(strings.TrimSpace(question.CorrectAnswer))
+	got, err := strconv.ParseBool(strings.TrimSpace(answer))
+	if err != nil || got != want {
+		return 0
+	}
+	return 1
+}
+
+// multiSelectEvaluator gives a share of the credit for each correct option
+// picked and takes one back for each wrong option picked.
+type multiSelectEvaluator struct{}
+
+func (multiSelectEvaluator) Validate(question *models.Question) error {
+	correct, err := parseAnswerList(question.CorrectAnswer)
+	if err != nil || len(correct) == 0 {
+		return fmt.Errorf("%w: correct answer must be a JSON array of option texts", ErrInvalidInput)
+	}
+	for _, answer := range correct {
+		if !hasOption(question, answer) {
+			return fmt.Errorf("%w: %q is not one of the options", ErrInvalidInput, answer)
+		}
+	}
+	return nil
+}
+
+func (multiSelectEvaluator) Evaluate(question *models.Question, answer string) float64 {
+	correct, err := parseAnswerList(question.CorrectAnswer)
+	if err != nil || len(correct) == 0 {
+		return 0
+	}
+	selected, err := parseAnswerList(answer)
+	if err != nil {
+		return 0
+	}
+
+	want := make(map[string]bool, len(correct))
+	for _, c := range correct {
+		want[c] = true
+	}
+	hits, misses := 0, 0
+	seen := make(map[string]bool, len(selected))
+	for _, s := range selected {
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		if want[s] {
+			hits++
+		} else {
+			misses++
+		}
+	}
+	return math.Max(0, float64(hits-misses)/float64(len(want)))
+}
+
+type numericEvaluator struct{}
+
+func (numericEvaluator) Validate(question *models.Question) error {
+	if _, err := parseNumber(question.CorrectAnswer); err != nil {
+		return fmt.Errorf("%w: correct answer must be a number", ErrInvalidInput)
+	}
+	if question.Tolerance < 0 {
+		return fmt.Errorf("%w: tolerance cannot be negative", ErrInvalidInput)
+	}
+	return nil
+}
+
+func (numericEvaluator) Evaluate(question *models.Question, answer string) float64 {
+	want, err := parseNumber(question.CorrectAnswer)
+	if err != nil {
+		return 0
+	}
+	got, err := parseNumber(answer)
+	if err != nil || math.Abs(got-want) > question.Tolerance {
+		return 0
+	}
+	return 1
+}
+
+// orderingEvaluator gives a share of the credit for each item placed in its
+// correct position.
+type orderingEvaluator struct{}
+
+func (orderingEvaluator) Validate(question *models.Question) error {
+	correct, err := parseAnswerList(question.CorrectAnswer)
+	if err != nil || len(correct) < 2 {
+		return fmt.Errorf("%w: correct answer must be a JSON array of at least two option texts", ErrInvalidInput)
+	}
+	if len(correct) != len(question.Options) {
+		return fmt.Errorf("%w: correct order must list every option exactly once", ErrInvalidInput)
+	}
+	seen := make(map[string]bool, len(correct))
+	for _, answer := range correct {
+		if seen[answer] || !hasOption(question, answer) {
+			return fmt.Errorf("%w: correct order must list every option exactly once", ErrInvalidInput)
+		}
+		seen[answer] = true
+	}
+	return nil
+}
+
+func (orderingEvaluator) Evaluate(question *models.Question, answer string) float64 {
+	correct, err := parseAnswerList(question.CorrectAnswer)
+	if err != nil || len(correct) == 0 {
+		return 0
+	}
+	given, err := parseAnswerList(answer)
+	if err != nil {
+		return 0
+	}
+	placed := 0
+	for i, item := range correct {
+		if i < len(given) && given[i] == item {
+			placed++
+		}
+	}
+	return float64(placed) / float64(len(correct))
+}
+
+// shortTextEvaluator accepts any of the listed answers, ignoring case,
+// surrounding and repeated whitespace, and accents.
+type shortTextEvaluator struct{}
+
+func (shortTextEvaluator) Validate(question *models.Question) error {
+	if len(acceptedAnswers(question.CorrectAnswer)) == 0 {
+		return fmt.Errorf("%w: at least one accepted answer is required", ErrInvalidInput)
+	}
+	return nil
+}
+
+func (shortTextEvaluator) Evaluate(question *models.Question, answer string) float64 {
+	got := normalizeText(answer)
+	if got == "" {
+		return 0
+	}
+	for _, accepted := range acceptedAnswers(question.CorrectAnswer) {
+		if normalizeText(accepted) == got {
+			return 1
+		}
+	}
+	return 0
+}
+
+func acceptedAnswers(correctAnswer string) []string {
+	answers, err := parseAnswerList(correctAnswer)
+	if err != nil {
+		answers = []string{correctAnswer}
+	}
+	accepted := answers[:0]
+	for _, answer := range answers {
+		if normalizeText(answer) != "" {
+			accepted = append(accepted, answer)
+		}
+	}
+	return accepted
+}
+
+// parseAnswerList decodes an answer made of several option texts.
+func parseAnswerList(answer string) ([]string, error) {
+	var list []string
+	if err := json.Unmarshal([]byte(answer), &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// parseNumber reads a number, accepting a decimal comma.
+func parseNumber(s string) (float64, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", ".")
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	return n, nil
+}
+
+func normalizeText(s string) string {
+	// Transformer chains keep state, so each call builds its own.
+	stripAccents := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(stripAccents, s)
+	if err != nil {
+		folded = s
+	}
+	return strings.ToLower(strings.Join(strings.Fields(folded), " "))
+}
+
+func hasOption(question *models.Question, text string) bool {
+	for _, option := range question.Options {
+		if option.Text == text {
+			return true
+		}
+	}
+	return false
+}
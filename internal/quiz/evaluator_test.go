@@ -0,0 +1,117 @@
+// backend/internal/quiz/evaluator_test.go
+package quiz
+
+import (
+	"errors"
+	"quiz-system/internal/models"
+	"testing"
+)
+
+func options(texts ...string) []models.Option {
+	list := make([]models.Option, len(texts))
+	for i, text := range texts {
+		list[i] = models.Option{ID: uint(i + 1), Position: i, Text: text}
+	}
+	return list
+}
+
+func TestEvaluateAnswer(t *testing.T) {
+	colors := options("Red", "Green", "Blue", "Yellow")
+	steps := options("Wake", "Wash", "Dress", "Leave")
+
+	tests := []struct {
+		name     string
+		question models.Question
+		answer   string
+		want     float64
+	}{
+		{"single choice correct", models.Question{CorrectAnswer: "Red", Options: colors}, "Red", 1},
+		{"single choice wrong", models.Question{CorrectAnswer: "Red", Options: colors}, "Blue", 0},
+		{"single choice is case sensitive", models.Question{CorrectAnswer: "Red", Options: colors}, "red", 0},
+
+		{"true false", models.Question{Type: models.QuestionTrueFalse, CorrectAnswer: "true"}, "true", 1},
+		{"true false ignores case and spaces", models.Question{Type: models.QuestionTrueFalse, CorrectAnswer: "false"}, " FALSE ", 1},
+		{"true false wrong", models.Question{Type: models.QuestionTrueFalse, CorrectAnswer: "false"}, "true", 0},
+		{"true false garbage", models.Question{Type: models.QuestionTrueFalse, CorrectAnswer: "true"}, "yes", 0},
+
+		{"multi select all", models.Question{Type: models.QuestionMultiSelect, CorrectAnswer: `["Red","Blue"]`, Options: colors}, `["Blue","Red"]`, 1},
+		{"multi select half", models.Question{Type: models.QuestionMultiSelect, CorrectAnswer: `["Red","Blue"]`, Options: colors}, `["Red"]`, 0.5},
+		{"multi select wrong pick takes a share back", models.Question{Type: models.QuestionMultiSelect, CorrectAnswer: `["Red","Blue"]`, Options: colors}, `["Red","Blue","Green"]`, 0.5},
+		{"multi select never below zero", models.Question{Type: models.QuestionMultiSelect, CorrectAnswer: `["Red","Blue"]`, Options: colors}, `["Green","Yellow","Red"]`, 0},
+		{"multi select duplicates count once", models.Question{Type: models.QuestionMultiSelect, CorrectAnswer: `["Red","Blue"]`, Options: colors}, `["Red","Red"]`, 0.5},
+		{"multi select malformed", models.Question{Type: models.QuestionMultiSelect, CorrectAnswer: `["Red","Blue"]`, Options: colors}, "Red", 0},
+
+		{"numeric exact", models.Question{Type: models.QuestionNumeric, CorrectAnswer: "3.14"}, "3.14", 1},
+		{"numeric within tolerance", models.Question{Type: models.QuestionNumeric, CorrectAnswer: "100", Tolerance: 5}, "104.5", 1},
+		{"numeric on the tolerance edge", models.Question{Type: models.QuestionNumeric, CorrectAnswer: "100", Tolerance: 5}, "95", 1},
+		{"numeric outside tolerance", models.Question{Type: models.QuestionNumeric, CorrectAnswer: "100", Tolerance: 5}, "105.5", 0},
+		{"numeric without tolerance", models.Question{Type: models.QuestionNumeric, CorrectAnswer: "100"}, "100.01", 0},
+		{"numeric decimal comma", models.Question{Type: models.QuestionNumeric, CorrectAnswer: "2.5", Tolerance: 0.1}, " 2,55 ", 1},
+		{"numeric not a number", models.Question{Type: models.QuestionNumeric, CorrectAnswer: "1"}, "one", 0},
+		{"numeric NaN", models.Question{Type: models.QuestionNumeric, CorrectAnswer: "1", Tolerance: 1e9}, "NaN", 0},
+
+		{"ordering correct", models.Question{Type: models.QuestionOrdering, CorrectAnswer: `["Wake","Wash","Dress","Leave"]`, Options: steps}, `["Wake","Wash","Dress","Leave"]`, 1},
+		{"ordering half in place", models.Question{Type: models.QuestionOrdering, CorrectAnswer: `["Wake","Wash","Dress","Leave"]`, Options: steps}, `["Wake","Wash","Leave","Dress"]`, 0.5},
+		{"ordering short answer", models.Question{Type: models.QuestionOrdering, CorrectAnswer: `["Wake","Wash","Dress","Leave"]`, Options: steps}, `["Wake"]`, 0.25},
+		{"ordering reversed", models.Question{Type: models.QuestionOrdering, CorrectAnswer: `["Wake","Wash","Dress","Leave"]`, Options: steps}, `["Leave","Dress","Wash","Wake"]`, 0},
+
+		{"short text exact", models.Question{Type: models.QuestionShortText, CorrectAnswer: "Paris"}, "Paris", 1},
+		{"short text case", models.Question{Type: models.QuestionShortText, CorrectAnswer: "Paris"}, "PARIS", 1},
+		{"short text whitespace", models.Question{Type: models.QuestionShortText, CorrectAnswer: "New York"}, "  new   york ", 1},
+		{"short text accents in answer", models.Question{Type: models.QuestionShortText, CorrectAnswer: "Sao Paulo"}, "São Paulo", 1},
+		{"short text accents in correct answer", models.Question{Type: models.QuestionShortText, CorrectAnswer: "Zürich"}, "zurich", 1},
+		{"short text one of several", models.Question{Type: models.QuestionShortText, CorrectAnswer: `["UK","United Kingdom"]`}, "united kingdom", 1},
+		{"short text wrong", models.Question{Type: models.QuestionShortText, CorrectAnswer: "Paris"}, "Lyon", 0},
+		{"short text empty", models.Question{Type: models.QuestionShortText, CorrectAnswer: "Paris"}, "   ", 0},
+
+		{"unknown type", models.Question{Type: "essay", CorrectAnswer: "x"}, "x", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := evaluateAnswer(&tt.question, tt.answer); got != tt.want {
+				t.Errorf("evaluateAnswer(%q) = %v, want %v", tt.answer, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateQuestion(t *testing.T) {
+	colors := options("Red", "Green", "Blue")
+
+	tests := []struct {
+		name     string
+		question models.Question
+		valid    bool
+	}{
+		{"single choice", models.Question{Text: "Q", CorrectAnswer: "Red", Options: colors}, true},
+		{"single choice answer not an option", models.Question{Text: "Q", CorrectAnswer: "Pink", Options: colors}, false},
+		{"missing text", models.Question{Text: " ", CorrectAnswer: "Red", Options: colors}, false},
+		{"negative weight", models.Question{Text: "Q", CorrectAnswer: "Red", Options: colors, Weight: -1}, false},
+		{"true false", models.Question{Text: "Q", Type: models.QuestionTrueFalse, CorrectAnswer: "false"}, true},
+		{"true false not a bool", models.Question{Text: "Q", Type: models.QuestionTrueFalse, CorrectAnswer: "maybe"}, false},
+		{"multi select", models.Question{Text: "Q", Type: models.QuestionMultiSelect, CorrectAnswer: `["Red","Blue"]`, Options: colors}, true},
+		{"multi select empty", models.Question{Text: "Q", Type: models.QuestionMultiSelect, CorrectAnswer: `[]`, Options: colors}, false},
+		{"multi select unknown option", models.Question{Text: "Q", Type: models.QuestionMultiSelect, CorrectAnswer: `["Pink"]`, Options: colors}, false},
+		{"numeric", models.Question{Text: "Q", Type: models.QuestionNumeric, CorrectAnswer: "4,5", Tolerance: 0.5}, true},
+		{"numeric negative tolerance", models.Question{Text: "Q", Type: models.QuestionNumeric, CorrectAnswer: "4", Tolerance: -1}, false},
+		{"ordering", models.Question{Text: "Q", Type: models.QuestionOrdering, CorrectAnswer: `["Blue","Red","Green"]`, Options: colors}, true},
+		{"ordering missing an option", models.Question{Text: "Q", Type: models.QuestionOrdering, CorrectAnswer: `["Blue","Red"]`, Options: colors}, false},
+		{"ordering repeats an option", models.Question{Text: "Q", Type: models.QuestionOrdering, CorrectAnswer: `["Blue","Red","Red"]`, Options: colors}, false},
+		{"short text", models.Question{Text: "Q", Type: models.QuestionShortText, CorrectAnswer: "Paris"}, true},
+		{"short text blank", models.Question{Text: "Q", Type: models.QuestionShortText, CorrectAnswer: `["", " "]`}, false},
+		{"unknown type", models.Question{Text: "Q", Type: "essay", CorrectAnswer: "x"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateQuestion(&tt.question)
+			if tt.valid && err != nil {
+				t.Errorf("validateQuestion() = %v, want nil", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("validateQuestion() = %v, want ErrInvalidInput", err)
+			}
+		})
+	}
+}
@@ -3,11 +3,17 @@ package quiz
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 	"quiz-system/internal/models"
 
 	"github.com/gorilla/mux"
+	"gorm.io/gorm"
 )
 
 type Handler struct {
@@ -18,6 +24,80 @@ func NewHandler(service *Service) *Handler {
     return &Handler{service: service}
 }
 
+// QuizUpdate holds the quiz metadata a creator may change. Omitted fields are
+// left as they are.
+type QuizUpdate struct {
+    Title       *string `json:"title"`
+    Description *string `json:"description"`
+    TimeLimit   *uint   `json:"time_limit"`
+    Scoring     *models.ScoringConfig `json:"scoring"`
+    Pacing      *models.PacingMode    `json:"pacing"`
+    SelfPacedLimit *uint              `json:"self_paced_limit"`
+}
+
+// QuestionUpdate holds the question fields a creator may change.
+type QuestionUpdate struct {
+    Type          *models.QuestionType `json:"type"`
+    Text          *string  `json:"text"`
+    CorrectAnswer *string  `json:"correct_answer"`
+    Tolerance     *float64 `json:"tolerance"`
+    Weight        *float64 `json:"weight"`
+    TimeLimit     *int     `json:"time_limit"`
+}
+
+// OptionUpdate holds the option fields a creator may change.
+type OptionUpdate struct {
+    Text *string `json:"text"`
+}
+
+// AnswerRequest is a player's answer to a question. SessionID picks the
+// session; without it the quiz's open session is used. The player comes from
+// the token and the score, time and IDs of the response are set by the server.
+type AnswerRequest struct {
+    SessionID      uint   `json:"session_id"`
+    QuizID         uint   `json:"quiz_id"`
+    QuestionID     uint   `json:"question_id"`
+    Answer         string `json:"answer"`
+    IdempotencyKey string `json:"idempotency_key"`
+}
+
+// SessionSettings configures a new session. Seed is only needed to replay the
+// shuffled order of an earlier session.
+type SessionSettings struct {
+    ShuffleQuestions bool   `json:"shuffle_questions"`
+    ShuffleOptions   bool   `json:"shuffle_options"`
+    Seed             *int64 `json:"seed"`
+    // Scoring overrides the quiz's scoring for this session only.
+    Scoring          *models.ScoringConfig `json:"scoring"`
+    // TeamMode plays the session in teams, scored by TeamScoring; Teams names
+    // the teams to create with it.
+    TeamMode         models.TeamMode    `json:"team_mode"`
+    TeamScoring      models.TeamScoring `json:"team_scoring"`
+    Teams            []string           `json:"teams"`
+    // Survival makes the session an elimination game.
+    Survival         *models.SurvivalConfig `json:"survival"`
+}
+
+// TeamRequest names a new team.
+type TeamRequest struct {
+    Name string `json:"name"`
+}
+
+// TeamAssignment moves a player to a team, or out of every team for null.
+type TeamAssignment struct {
+    TeamID *uint `json:"teamId"`
+}
+
+// RemovalRequest gives the reason a player is kicked or banned.
+type RemovalRequest struct {
+    Reason string `json:"reason"`
+}
+
+// OrderRequest lists question or option IDs in their new order.
+type OrderRequest struct {
+    IDs []uint `json:"ids"`
+}
+
 func (h *Handler) CreateQuiz(w http.ResponseWriter, r *http.Request) {
     userID, ok := r.Context().Value("user_id").(uint)
     if !ok {
@@ -34,23 +114,40 @@ func (h *Handler) CreateQuiz(w http.ResponseWriter, r *http.Request) {
     quiz.CreatorID = userID
 
     if err := h.service.CreateQuiz(&quiz); err != nil {
-        http.Error(w, err.Error(), http.StatusInternalServerError)
+        writeServiceError(w, err)
         return
     }
 
     json.NewEncoder(w).Encode(quiz)
 }
 
+// StartQuiz, JoinQuiz and GetLeaderboard act on a live session. Their {code}
+// is either a session join code or a quiz code standing for that quiz's
+// current session.
 func (h *Handler) StartQuiz(w http.ResponseWriter, r *http.Request) {
     vars := mux.Vars(r)
-    quizCode := vars["quizCode"]
+    quizCode := vars["code"]
     userID := r.Context().Value("user_id").(uint)
 
     log.Printf("Starting quiz %s for user %d", quizCode, userID)
 
-    if err := h.service.StartQuiz(quizCode, userID); err != nil {
+    var session *models.QuizSession
+    err := h.service.RunInRoom(quizCode, func() error {
+        var err error
+        session, err = h.service.StartQuiz(quizCode, userID)
+        return err
+    })
+    if err != nil {
         log.Printf("Error starting quiz: %v", err)
-        http.Error(w, err.Error(), http.StatusBadRequest)
+        writeServiceError(w, err)
+        return
+    }
+
+    // A quiz code without a session only had its lobby opened.
+    if session.State == models.SessionLobby {
+        log.Printf("Opened lobby %s for quiz %s", session.JoinCode, quizCode)
+        w.WriteHeader(http.StatusCreated)
+        json.NewEncoder(w).Encode(map[string]string{"status": "Lobby opened", "join_code": session.JoinCode})
         return
     }
 
@@ -62,8 +159,9 @@ func (h *Handler) StartQuiz(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetQuiz(w http.ResponseWriter, r *http.Request) {
     vars := mux.Vars(r)
     quizCode := vars["quizCode"]
+    userID, _ := r.Context().Value("user_id").(uint)
 
-    quiz, err := h.service.GetQuizByCode(quizCode)
+    quiz, err := h.service.GetQuizForUser(quizCode, userID)
     if err != nil {
         http.Error(w, "Quiz not found", http.StatusNotFound)
         return
@@ -76,33 +174,44 @@ func (h *Handler) GetQuiz(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) JoinQuiz(w http.ResponseWriter, r *http.Request) {
     vars := mux.Vars(r)
-    quizCode := vars["quizCode"]
+    quizCode := vars["code"]
     userID, ok := r.Context().Value("user_id").(uint)
     if !ok {
         http.Error(w, "Unauthorized", http.StatusUnauthorized)
         return
     }
-    if err := h.service.JoinQuiz(quizCode, userID); err != nil {
-        http.Error(w, err.Error(), http.StatusBadRequest)
+    session, err := h.service.JoinQuiz(quizCode, userID)
+    if err != nil {
+        writeServiceError(w, err)
         return
     }
 
     w.WriteHeader(http.StatusOK)
+    json.NewEncoder(w).Encode(session)
 }
 
 func (h *Handler) SubmitAnswer(w http.ResponseWriter, r *http.Request) {
-    var response models.UserQuizResponse
-  
-    if err := json.NewDecoder(r.Body).Decode(&response); err != nil {
+    var req AnswerRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
         http.Error(w, err.Error(), http.StatusBadRequest)
         return
     }
 
-    response.UserID = r.Context().Value("user_id").(uint)
+    response := models.UserQuizResponse{
+        UserID:         r.Context().Value("user_id").(uint),
+        SessionID:      req.SessionID,
+        QuizID:         req.QuizID,
+        QuestionID:     req.QuestionID,
+        Answer:         req.Answer,
+        IdempotencyKey: req.IdempotencyKey,
+    }
+    if key := r.Header.Get("Idempotency-Key"); key != "" {
+        response.IdempotencyKey = key
+    }
 
-    score, err := h.service.ProcessAnswer(&response)
+    score, err := h.service.SubmitAnswer(&response)
     if err != nil {
-        http.Error(w, err.Error(), http.StatusInternalServerError)
+        writeServiceError(w, err)
         return
     }
 
@@ -125,13 +234,570 @@ func (h *Handler) GetMyQuizzes(w http.ResponseWriter, r *http.Request) {
 // backend/internal/quiz/handler.go
 func (h *Handler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
     vars := mux.Vars(r)
-    quizCode := vars["quizCode"]
+    quizCode := vars["code"]
 
     leaderboard, err := h.service.GetLeaderboard(quizCode)
     if err != nil {
-        http.Error(w, err.Error(), http.StatusInternalServerError)
+        writeServiceError(w, err)
+        return
+    }
+
+    json.NewEncoder(w).Encode(leaderboard)
+}
+
+func (h *Handler) CreateSession(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    quizCode := vars["quizCode"]
+    userID := r.Context().Value("user_id").(uint)
+
+    // The body is optional; an empty one creates a session without shuffling.
+    var settings SessionSettings
+    if err := json.NewDecoder(r.Body).Decode(&settings); err != nil && err != io.EOF {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    session, err := h.service.CreateSession(quizCode, userID, settings)
+    if err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(session)
+}
+
+func (h *Handler) GetSessions(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    quizCode := vars["quizCode"]
+    userID := r.Context().Value("user_id").(uint)
+
+    sessions, err := h.service.GetSessions(quizCode, userID)
+    if err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    json.NewEncoder(w).Encode(sessions)
+}
+
+func (h *Handler) GetSession(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    code := vars["code"]
+
+    session, err := h.service.GetSession(code)
+    if err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    json.NewEncoder(w).Encode(session)
+}
+
+// OpenSession opens a draft session's lobby to players.
+func (h *Handler) OpenSession(w http.ResponseWriter, r *http.Request) {
+    h.hostControl(w, r, h.service.OpenSession)
+}
+
+// RevealResults closes the current question and shows its results.
+func (h *Handler) RevealResults(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    code := vars["code"]
+    userID := r.Context().Value("user_id").(uint)
+
+    err := h.service.RunInRoom(code, func() error {
+        return h.service.RevealResults(code, userID)
+    })
+    if err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    json.NewEncoder(w).Encode(map[string]string{"status": "Results revealed"})
+}
+
+// NextQuestion moves a host-paced session on from the question in the body.
+func (h *Handler) NextQuestion(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    code := vars["code"]
+    userID := r.Context().Value("user_id").(uint)
+
+    var req struct {
+        CurrentIndex int `json:"current_index"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    err := h.service.RunInRoom(code, func() error {
+        return h.service.HandleNextQuestion(code, userID, req.CurrentIndex)
+    })
+    if err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    json.NewEncoder(w).Encode(map[string]string{"status": "Moved to the next question"})
+}
+
+// PauseSession freezes the timers of a game in progress.
+func (h *Handler) PauseSession(w http.ResponseWriter, r *http.Request) {
+    h.hostControl(w, r, h.service.PauseSession)
+}
+
+// ResumeSession restarts a paused game.
+func (h *Handler) ResumeSession(w http.ResponseWriter, r *http.Request) {
+    h.hostControl(w, r, h.service.ResumeSession)
+}
+
+// SkipQuestion drops the current question without scoring it.
+func (h *Handler) SkipQuestion(w http.ResponseWriter, r *http.Request) {
+    h.hostControl(w, r, h.service.SkipQuestion)
+}
+
+// EndSession ends the game early with a final leaderboard.
+func (h *Handler) EndSession(w http.ResponseWriter, r *http.Request) {
+    h.hostControl(w, r, h.service.EndSession)
+}
+
+// hostControl runs a host action on the session in the URL, in order with
+// the room's WebSocket messages, and replies with the updated session.
+func (h *Handler) hostControl(w http.ResponseWriter, r *http.Request, control func(code string, userID uint) (*models.QuizSession, error)) {
+    vars := mux.Vars(r)
+    code := vars["code"]
+    userID := r.Context().Value("user_id").(uint)
+
+    var session *models.QuizSession
+    err := h.service.RunInRoom(code, func() error {
+        var err error
+        session, err = control(code, userID)
+        return err
+    })
+    if err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    json.NewEncoder(w).Encode(session)
+}
+
+// ScreenTokenResponse is a spectator token for a session's display.
+type ScreenTokenResponse struct {
+    Token     string    `json:"token"`
+    ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// CreateScreenToken mints a read-only token for a display to watch the session.
+func (h *Handler) CreateScreenToken(w http.ResponseWriter, r *http.Request) {
+    code := mux.Vars(r)["code"]
+    userID := r.Context().Value("user_id").(uint)
+
+    token, expiresAt, err := h.service.ScreenToken(code, userID)
+    if err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(ScreenTokenResponse{Token: token, ExpiresAt: expiresAt})
+}
+
+// GetTeams lists the teams of a session and their players.
+func (h *Handler) GetTeams(w http.ResponseWriter, r *http.Request) {
+    code := mux.Vars(r)["code"]
+
+    teams, err := h.service.GetTeams(code)
+    if err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    json.NewEncoder(w).Encode(teams)
+}
+
+// CreateTeam adds a team to the session.
+func (h *Handler) CreateTeam(w http.ResponseWriter, r *http.Request) {
+    code := mux.Vars(r)["code"]
+    userID := r.Context().Value("user_id").(uint)
+
+    var request TeamRequest
+    if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    team, err := h.service.CreateTeam(code, userID, request.Name)
+    if err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(team)
+}
+
+// BalanceTeams spreads the players evenly over the teams.
+func (h *Handler) BalanceTeams(w http.ResponseWriter, r *http.Request) {
+    code := mux.Vars(r)["code"]
+    userID := r.Context().Value("user_id").(uint)
+
+    teams, err := h.service.BalanceTeams(code, userID)
+    if err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    json.NewEncoder(w).Encode(teams)
+}
+
+// ChooseTeam puts the caller in a team of a self-select session.
+func (h *Handler) ChooseTeam(w http.ResponseWriter, r *http.Request) {
+    code := mux.Vars(r)["code"]
+    userID := r.Context().Value("user_id").(uint)
+    teamID, err := idVar(r, "teamID")
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    if err := h.service.ChooseTeam(code, userID, teamID); err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// AssignTeam lets the host move a player to a team.
+func (h *Handler) AssignTeam(w http.ResponseWriter, r *http.Request) {
+    code := mux.Vars(r)["code"]
+    hostID := r.Context().Value("user_id").(uint)
+    userID, err := idVar(r, "userID")
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    var assignment TeamAssignment
+    if err := json.NewDecoder(r.Body).Decode(&assignment); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    if err := h.service.AssignTeam(code, hostID, userID, assignment.TeamID); err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTeamLeaderboard returns the team scores of the session.
+func (h *Handler) GetTeamLeaderboard(w http.ResponseWriter, r *http.Request) {
+    code := mux.Vars(r)["code"]
+
+    leaderboard, err := h.service.GetTeamLeaderboard(code)
+    if err != nil {
+        writeServiceError(w, err)
         return
     }
 
     json.NewEncoder(w).Encode(leaderboard)
-}
\ No newline at end of file
+}
+
+// KickParticipant removes a player from the session.
+func (h *Handler) KickParticipant(w http.ResponseWriter, r *http.Request) {
+    h.removeParticipant(w, r, h.service.KickParticipant)
+}
+
+// BanParticipant removes a player from the session for good.
+func (h *Handler) BanParticipant(w http.ResponseWriter, r *http.Request) {
+    h.removeParticipant(w, r, h.service.BanParticipant)
+}
+
+func (h *Handler) removeParticipant(w http.ResponseWriter, r *http.Request, remove func(code string, hostID, userID uint, reason string) error) {
+    code := mux.Vars(r)["code"]
+    hostID := r.Context().Value("user_id").(uint)
+    userID, err := idVar(r, "userID")
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    // The body is optional; without it the player is removed without a reason.
+    var request RemovalRequest
+    if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    err = h.service.RunInRoom(code, func() error {
+        return remove(code, hostID, userID, request.Reason)
+    })
+    if err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// ArchiveSession moves a finished session out of the active history.
+func (h *Handler) ArchiveSession(w http.ResponseWriter, r *http.Request) {
+    h.hostControl(w, r, h.service.ArchiveSession)
+}
+
+func (h *Handler) UpdateQuiz(w http.ResponseWriter, r *http.Request) {
+    quizCode := mux.Vars(r)["quizCode"]
+    userID := r.Context().Value("user_id").(uint)
+
+    var update QuizUpdate
+    if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    quiz, err := h.service.UpdateQuiz(quizCode, userID, update)
+    if err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    json.NewEncoder(w).Encode(quiz)
+}
+
+func (h *Handler) DeleteQuiz(w http.ResponseWriter, r *http.Request) {
+    quizCode := mux.Vars(r)["quizCode"]
+    userID := r.Context().Value("user_id").(uint)
+
+    if err := h.service.DeleteQuiz(quizCode, userID); err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) DuplicateQuiz(w http.ResponseWriter, r *http.Request) {
+    quizCode := mux.Vars(r)["quizCode"]
+    userID := r.Context().Value("user_id").(uint)
+
+    quiz, err := h.service.DuplicateQuiz(quizCode, userID)
+    if err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(quiz)
+}
+
+func (h *Handler) AddQuestion(w http.ResponseWriter, r *http.Request) {
+    quizCode := mux.Vars(r)["quizCode"]
+    userID := r.Context().Value("user_id").(uint)
+
+    var question models.Question
+    if err := json.NewDecoder(r.Body).Decode(&question); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    created, err := h.service.AddQuestion(quizCode, userID, &question)
+    if err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(created)
+}
+
+func (h *Handler) UpdateQuestion(w http.ResponseWriter, r *http.Request) {
+    quizCode := mux.Vars(r)["quizCode"]
+    userID := r.Context().Value("user_id").(uint)
+    questionID, err := idVar(r, "questionID")
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    var update QuestionUpdate
+    if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    question, err := h.service.UpdateQuestion(quizCode, userID, questionID, update)
+    if err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    json.NewEncoder(w).Encode(question)
+}
+
+func (h *Handler) DeleteQuestion(w http.ResponseWriter, r *http.Request) {
+    quizCode := mux.Vars(r)["quizCode"]
+    userID := r.Context().Value("user_id").(uint)
+    questionID, err := idVar(r, "questionID")
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    if err := h.service.DeleteQuestion(quizCode, userID, questionID); err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ReorderQuestions(w http.ResponseWriter, r *http.Request) {
+    quizCode := mux.Vars(r)["quizCode"]
+    userID := r.Context().Value("user_id").(uint)
+
+    var order OrderRequest
+    if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    quiz, err := h.service.ReorderQuestions(quizCode, userID, order.IDs)
+    if err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    json.NewEncoder(w).Encode(quiz)
+}
+
+func (h *Handler) AddOption(w http.ResponseWriter, r *http.Request) {
+    quizCode := mux.Vars(r)["quizCode"]
+    userID := r.Context().Value("user_id").(uint)
+    questionID, err := idVar(r, "questionID")
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    var option models.Option
+    if err := json.NewDecoder(r.Body).Decode(&option); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    created, err := h.service.AddOption(quizCode, userID, questionID, &option)
+    if err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    w.WriteHeader(http.StatusCreated)
+    json.NewEncoder(w).Encode(created)
+}
+
+func (h *Handler) UpdateOption(w http.ResponseWriter, r *http.Request) {
+    quizCode := mux.Vars(r)["quizCode"]
+    userID := r.Context().Value("user_id").(uint)
+    questionID, err := idVar(r, "questionID")
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    optionID, err := idVar(r, "optionID")
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    var update OptionUpdate
+    if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    option, err := h.service.UpdateOption(quizCode, userID, questionID, optionID, update)
+    if err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    json.NewEncoder(w).Encode(option)
+}
+
+func (h *Handler) DeleteOption(w http.ResponseWriter, r *http.Request) {
+    quizCode := mux.Vars(r)["quizCode"]
+    userID := r.Context().Value("user_id").(uint)
+    questionID, err := idVar(r, "questionID")
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    optionID, err := idVar(r, "optionID")
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    if err := h.service.DeleteOption(quizCode, userID, questionID, optionID); err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ReorderOptions(w http.ResponseWriter, r *http.Request) {
+    quizCode := mux.Vars(r)["quizCode"]
+    userID := r.Context().Value("user_id").(uint)
+    questionID, err := idVar(r, "questionID")
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    var order OrderRequest
+    if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    question, err := h.service.ReorderOptions(quizCode, userID, questionID, order.IDs)
+    if err != nil {
+        writeServiceError(w, err)
+        return
+    }
+
+    json.NewEncoder(w).Encode(question)
+}
+
+// idVar parses a numeric route variable.
+func idVar(r *http.Request, name string) (uint, error) {
+    id, err := strconv.ParseUint(mux.Vars(r)[name], 10, 64)
+    if err != nil {
+        return 0, fmt.Errorf("invalid %s", name)
+    }
+    return uint(id), nil
+}
+
+// writeServiceError maps errors returned by the service to HTTP status codes.
+func writeServiceError(w http.ResponseWriter, err error) {
+    status := http.StatusInternalServerError
+    switch {
+    case errors.Is(err, ErrNotHost), errors.Is(err, ErrBanned):
+        status = http.StatusForbidden
+    case errors.Is(err, gorm.ErrRecordNotFound):
+        status = http.StatusNotFound
+    case errors.Is(err, ErrNoActiveQuestion), errors.Is(err, ErrAnswerTooLate),
+        errors.Is(err, ErrAlreadyAnswered), errors.Is(err, ErrInvalidState), errors.Is(err, ErrEliminated):
+        status = http.StatusConflict
+    case errors.Is(err, ErrQuizInUse), errors.Is(err, ErrWrongPacing), errors.Is(err, ErrResultsShown),
+        errors.Is(err, ErrWrongTeamMode):
+        status = http.StatusConflict
+    case errors.Is(err, ErrNoQuestions), errors.Is(err, ErrInvalidInput):
+        status = http.StatusBadRequest
+    }
+    http.Error(w, err.Error(), status)
+}
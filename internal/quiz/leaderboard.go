@@ -0,0 +1,159 @@
+// backend/internal/quiz/leaderboard.go
+package quiz
+
+import (
+	"log"
+	"quiz-system/internal/models"
+	"quiz-system/pkg/cache"
+	"quiz-system/pkg/websocket"
+	"time"
+)
+
+const (
+	// leaderboardTopN is how many players leaderboard_update messages list.
+	leaderboardTopN = 10
+
+	// leaderboardInterval is the shortest time between two leaderboard_update
+	// messages of a session; answers arriving in between are batched.
+	leaderboardInterval = time.Second
+)
+
+// LeaderboardStanding is a player's place on the live leaderboard.
+type LeaderboardStanding struct {
+	UserID   uint   `json:"userId"`
+	Username string `json:"username"`
+	Rank     int    `json:"rank"`
+	Score    int    `json:"score"`
+	Delta    int    `json:"delta"` // points gained since the previous update
+	Team     string `json:"team,omitempty"`
+}
+
+// leaderboardFeed throttles the leaderboard_update messages of each session
+// and remembers the scores last sent, to report what changed since. Both are
+// kept in Redis, so the throttle and the deltas hold across instances.
+type leaderboardFeed struct {
+	store *cache.RedisCache
+}
+
+func newLeaderboardFeed(store *cache.RedisCache) *leaderboardFeed {
+	return &leaderboardFeed{store: store}
+}
+
+// schedule runs publish once the interval has passed, unless an update of the
+// session is already scheduled on this or another instance.
+func (f *leaderboardFeed) schedule(quizCode string, publish func()) {
+	claimed, err := f.store.ClaimLeaderboardUpdate(quizCode, leaderboardInterval)
+	if err != nil {
+		log.Printf("Error scheduling leaderboard update of %s: %v", quizCode, err)
+		return
+	}
+	if claimed {
+		time.AfterFunc(leaderboardInterval, publish)
+	}
+}
+
+// standings ranks the entries, which are sorted by score, and records their
+// scores as the ones last sent. Tied players share a rank.
+func (f *leaderboardFeed) standings(quizCode string, entries []models.LeaderboardEntry) []LeaderboardStanding {
+	current := make(map[uint]int, len(entries))
+	for _, entry := range entries {
+		current[entry.UserID] = entry.TotalScore
+	}
+	previous, err := f.store.SwapSentScores(quizCode, current)
+	if err != nil {
+		log.Printf("Error recording leaderboard of %s: %v", quizCode, err)
+	}
+	return rankEntries(entries, previous)
+}
+
+// rankEntries ranks entries sorted by score, with deltas against the previous
+// scores. Tied players share a rank.
+func rankEntries(entries []models.LeaderboardEntry, previous map[uint]int) []LeaderboardStanding {
+	standings := make([]LeaderboardStanding, len(entries))
+	for i, entry := range entries {
+		rank := i + 1
+		if i > 0 && entry.TotalScore == entries[i-1].TotalScore {
+			rank = standings[i-1].Rank
+		}
+		standings[i] = LeaderboardStanding{
+			UserID:   entry.UserID,
+			Username: entry.Username,
+			Rank:     rank,
+			Score:    entry.TotalScore,
+			Delta:    entry.TotalScore - previous[entry.UserID],
+			Team:     entry.TeamName,
+		}
+	}
+	return standings
+}
+
+// forget drops what the feed remembers about a session.
+func (f *leaderboardFeed) forget(quizCode string) {
+	if err := f.store.ForgetLeaderboardUpdates(quizCode); err != nil {
+		log.Printf("Error forgetting leaderboard updates of %s: %v", quizCode, err)
+	}
+}
+
+// addScore adds the points of a recorded answer to the session's live
+// leaderboard and schedules a leaderboard_update. The database stays the
+// record; the live leaderboard is rebuilt from it when the session finishes.
+func (s *Service) addScore(session *models.QuizSession, userID uint, points int) {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		log.Printf("Error loading user %d for the leaderboard: %v", userID, err)
+		return
+	}
+	if err := s.cache.IncrementScore(session.JoinCode, userID, user.Username, points); err != nil {
+		log.Printf("Error updating live leaderboard of session %s: %v", session.JoinCode, err)
+		return
+	}
+	s.leaderboards.schedule(session.JoinCode, func() {
+		s.publishLeaderboard(session)
+	})
+}
+
+// publishLeaderboard sends the room the top players, and each player their own
+// standing.
+func (s *Service) publishLeaderboard(session *models.QuizSession) {
+	entries, err := s.cache.GetLeaderboard(session.JoinCode)
+	if err != nil {
+		log.Printf("Error reading live leaderboard of session %s: %v", session.JoinCode, err)
+		return
+	}
+	teams := s.teamBoard(session, entries)
+	standings := s.leaderboards.standings(session.JoinCode, entries)
+	top := standings
+	if len(top) > leaderboardTopN {
+		top = top[:leaderboardTopN]
+	}
+	byUser := make(map[uint]LeaderboardStanding, len(standings))
+	for _, standing := range standings {
+		byUser[standing.UserID] = standing
+	}
+
+	s.wsHub.BroadcastEach(session.JoinCode, "leaderboard_update", func(userID uint, role websocket.Role) interface{} {
+		update := LeaderboardUpdateMessage{
+			SessionID: session.ID,
+			Top:       top,
+			Players:   len(standings),
+			Teams:     teams,
+		}
+		if standing, ok := byUser[userID]; ok && role == websocket.RolePlayer {
+			update.You = &standing
+		}
+		return update
+	})
+}
+
+// updateLeaderboard reconciles the live leaderboard with the scores stored in
+// the database.
+func (s *Service) updateLeaderboard(session *models.QuizSession) error {
+	entries, err := s.repo.GetLeaderboard(session.ID)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("%v scores of the players", entries)
+
+	return s.cache.UpdateLeaderboard(session.JoinCode, entries)
+}
@@ -0,0 +1,310 @@
+// backend/internal/quiz/lifecycle.go
+package quiz
+
+import (
+	"errors"
+	"log"
+	"quiz-system/internal/models"
+	"quiz-system/pkg/websocket"
+	"time"
+)
+
+// requireState fails with a StateError unless the session is in one of the
+// allowed states.
+func requireState(session *models.QuizSession, op string, allowed ...models.SessionState) error {
+	for _, state := range allowed {
+		if session.State == state {
+			return nil
+		}
+	}
+	log.Printf("Cannot %s: session %s is %s", op, session.JoinCode, session.State)
+	return &StateError{Op: op, State: session.State}
+}
+
+// transition moves the session to the next state, persists it and tells the
+// room. It fails with a StateError when the lifecycle does not allow the move,
+// or when the stored state is no longer the one in session because another
+// request moved the session first.
+func (s *Service) transition(session *models.QuizSession, op string, next models.SessionState) error {
+	if !session.State.CanTransitionTo(next) {
+		log.Printf("Cannot %s: session %s is %s", op, session.JoinCode, session.State)
+		return &StateError{Op: op, State: session.State}
+	}
+
+	previous, startedAt, endedAt := session.State, session.StartedAt, session.EndedAt
+	now := time.Now()
+	session.State = next
+	switch next {
+	case models.SessionInProgress:
+		if session.StartedAt == nil {
+			session.StartedAt = &now
+		}
+	case models.SessionFinished:
+		session.EndedAt = &now
+	}
+	moved, err := s.repo.TransitionSession(session, previous)
+	if err != nil || !moved {
+		session.State, session.StartedAt, session.EndedAt = previous, startedAt, endedAt
+	}
+	if err != nil {
+		return err
+	}
+	if !moved {
+		current := previous
+		if stored, err := s.repo.GetSessionByID(session.ID); err == nil {
+			current = stored.State
+		}
+		log.Printf("Cannot %s: session %s moved from %s to %s meanwhile", op, session.JoinCode, previous, current)
+		return &StateError{Op: op, State: current}
+	}
+	log.Printf("Session %s moved from %s to %s", session.JoinCode, previous, next)
+
+	if s.wsHub != nil {
+		s.wsHub.BroadcastMessage(session.JoinCode, "session_state", SessionStateMessage{
+			SessionID: session.ID,
+			State:     next,
+			Previous:  previous,
+		})
+	}
+	return nil
+}
+
+// OpenSession opens a draft session's lobby to players.
+func (s *Service) OpenSession(code string, userID uint) (*models.QuizSession, error) {
+	session, err := s.authorizeHost(code, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.transition(session, "open the lobby", models.SessionLobby); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// ArchiveSession moves a finished session out of the active history.
+func (s *Service) ArchiveSession(code string, userID uint) (*models.QuizSession, error) {
+	session, err := s.authorizeHost(code, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.transition(session, "archive the session", models.SessionArchived); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// PauseSession freezes a game in progress: every question timer, and the
+// overall time limit of a self-paced session, stops until the host resumes.
+// Answers are refused while the session is paused.
+func (s *Service) PauseSession(code string, userID uint) (*models.QuizSession, error) {
+	session, err := s.authorizeHost(code, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireState(session, "pause the quiz", models.SessionInProgress); err != nil {
+		return nil, err
+	}
+	// The timers stop before the state changes, so none runs out meanwhile.
+	paused := s.clock.pause(session.JoinCode)
+	if err := s.transition(session, "pause the quiz", models.SessionPaused); err != nil {
+		// Unless another request paused the session first, it plays on.
+		var stateErr *StateError
+		if paused && !(errors.As(err, &stateErr) && stateErr.State == models.SessionPaused) {
+			s.clock.resume(session.JoinCode)
+		}
+		return nil, err
+	}
+	return session, nil
+}
+
+// ResumeSession restarts a paused game. Players keep the time they had left
+// and are sent their new deadlines.
+func (s *Service) ResumeSession(code string, userID uint) (*models.QuizSession, error) {
+	session, err := s.authorizeHost(code, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.resume(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// resume moves a paused session back into play and restarts its timers.
+func (s *Service) resume(session *models.QuizSession) error {
+	if err := s.transition(session, "resume the quiz", models.SessionInProgress); err != nil {
+		return err
+	}
+	shift, resumed := s.clock.resume(session.JoinCode)
+	if resumed && session.Deadline != nil {
+		deadline := session.Deadline.Add(shift)
+		session.Deadline = &deadline
+		if err := s.repo.UpdateSession(session); err != nil {
+			log.Printf("Error saving new deadline of session %s: %v", session.JoinCode, err)
+		}
+		s.armSessionDeadline(session)
+	}
+
+	deadline := unixMilli(session.Deadline)
+	s.wsHub.BroadcastEach(session.JoinCode, "quiz_resumed", func(userID uint, role websocket.Role) interface{} {
+		message := QuizResumedMessage{
+			SessionID: session.ID,
+			Deadline:  deadline,
+		}
+		if d, ok, _ := s.clock.peek(session.JoinCode, userID); ok && role == websocket.RolePlayer {
+			message.QuestionID = d.QuestionID
+			message.ExpiresAt = d.ExpiresAt().UnixMilli()
+		}
+		return message
+	})
+
+	if session.IsSelfPaced() {
+		s.sendPendingQuestions(session)
+	}
+	return nil
+}
+
+// sendPendingQuestions sends self-paced players who answered just before the
+// pause, and so were not sent their next question, the question they are on.
+func (s *Service) sendPendingQuestions(session *models.QuizSession) {
+	userIDs, err := s.repo.GetParticipantIDs(session.ID)
+	if err != nil {
+		log.Printf("Error getting participants of session %s: %v", session.JoinCode, err)
+		return
+	}
+	for _, userID := range userIDs {
+		if _, ok, err := s.clock.peek(session.JoinCode, userID); ok || err != nil {
+			continue
+		}
+		progress, err := s.repo.GetUserProgress(userID, session)
+		if err != nil {
+			log.Printf("Error loading progress of user %d: %v", userID, err)
+			continue
+		}
+		s.sendNextQuestion(session, userID, progress.NextIndex)
+	}
+}
+
+// SkipQuestion drops the current question of a host-paced session, for
+// example when it turns out to be broken: answers already given are deleted
+// so it scores nothing, the streaks they changed are put back, and the
+// players move on to the next question. A paused session resumes with the
+// next question.
+func (s *Service) SkipQuestion(code string, userID uint) (*models.QuizSession, error) {
+	session, err := s.authorizeHost(code, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireState(session, "skip the question", models.SessionInProgress, models.SessionPaused); err != nil {
+		return nil, err
+	}
+	if session.IsSelfPaced() {
+		return nil, ErrWrongPacing
+	}
+
+	questions, err := s.sessionQuestions(session)
+	if err != nil {
+		return nil, err
+	}
+	if session.CurrentIndex >= len(questions) {
+		return nil, ErrNoActiveQuestion
+	}
+	question := questions[session.CurrentIndex]
+
+	// Close the question without scoring whoever is still on the clock.
+	s.clock.clear(session.JoinCode)
+	if session.State == models.SessionPaused {
+		if err := s.transition(session, "skip the question", models.SessionInProgress); err != nil {
+			return nil, err
+		}
+	}
+
+	answered, err := s.repo.GetQuestionResponses(session.ID, question.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.DeleteQuestionResponses(session.ID, question.ID); err != nil {
+		return nil, err
+	}
+	s.restoreStreaks(session, questions, answered)
+	s.undoSurvival(session, session.CurrentIndex)
+	if err := s.updateLeaderboard(session); err != nil {
+		log.Printf("Error updating leaderboard: %v", err)
+	}
+
+	log.Printf("Skipping question %d of session %s", question.ID, session.JoinCode)
+	s.wsHub.BroadcastMessage(session.JoinCode, "question_skipped", QuestionSkippedMessage{
+		SessionID:  session.ID,
+		QuestionID: question.ID,
+		Index:      session.CurrentIndex,
+	})
+	s.publishLeaderboard(session)
+
+	if err := s.advanceTo(session, questions, session.CurrentIndex+1); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// restoreStreaks recomputes the streak of each player who answered a skipped
+// question from the answers they have left. Only answers change a streak, so
+// questions a player has no answer to are passed over.
+func (s *Service) restoreStreaks(session *models.QuizSession, questions []models.Question, answered []models.UserQuizResponse) {
+	for _, skipped := range answered {
+		responses, err := s.repo.GetUserResponses(session.ID, skipped.UserID)
+		if err != nil {
+			log.Printf("Error loading answers of user %d: %v", skipped.UserID, err)
+			continue
+		}
+		answers := make(map[uint]string, len(responses))
+		for _, response := range responses {
+			answers[response.QuestionID] = response.Answer
+		}
+
+		streak := 0
+		for i := len(questions) - 1; i >= 0; i-- {
+			answer, ok := answers[questions[i].ID]
+			if !ok {
+				continue
+			}
+			if evaluateAnswer(&questions[i], answer) < 1 {
+				break
+			}
+			streak++
+		}
+		if err := s.repo.SetStreak(session.ID, skipped.UserID, streak); err != nil {
+			log.Printf("Error restoring streak of user %d: %v", skipped.UserID, err)
+		}
+	}
+}
+
+// EndSession ends a game before its last question and sends everyone the
+// final leaderboard. Unanswered questions score nothing.
+func (s *Service) EndSession(code string, userID uint) (*models.QuizSession, error) {
+	session, err := s.authorizeHost(code, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.endSession(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// endSession finishes a session and sends everyone the final leaderboard. It
+// fails with a StateError if the session already finished.
+func (s *Service) endSession(session *models.QuizSession) error {
+	if err := s.finishSession(session); err != nil {
+		return err
+	}
+	leaderboard, err := s.cache.GetLeaderboard(session.JoinCode)
+	if err != nil {
+		log.Printf("Error retrieving leaderboard from cache: %v", err)
+	}
+	teams := s.teamBoard(session, leaderboard)
+	s.wsHub.BroadcastMessage(session.JoinCode, "final_leaderboard", leaderboard)
+	if teams != nil {
+		s.wsHub.BroadcastMessage(session.JoinCode, "final_team_leaderboard", teams)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// backend/internal/quiz/lifecycle_test.go
+package quiz
+
+import (
+	"errors"
+	"quiz-system/internal/models"
+	"testing"
+)
+
+// wantState checks that err is a StateError reporting the given state.
+func wantState(t *testing.T, op string, err error, state models.SessionState) {
+	t.Helper()
+	var stateErr *StateError
+	if !errors.As(err, &stateErr) || stateErr.State != state {
+		t.Errorf("%s = %v, want a StateError for %s", op, err, state)
+	}
+}
+
+// stored returns the state of the game's session in the database.
+func (g *game) stored(t *testing.T) models.SessionState {
+	t.Helper()
+	session, err := g.service.repo.GetSessionByID(g.session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return session.State
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	g := newGame(t)
+	code := g.session.JoinCode
+	if state := g.stored(t); state != models.SessionLobby {
+		t.Fatalf("new game is %s, want lobby", state)
+	}
+
+	_, err := g.service.OpenSession(code, g.host)
+	wantState(t, "OpenSession() in the lobby", err, models.SessionLobby)
+	_, err = g.service.PauseSession(code, g.host)
+	wantState(t, "PauseSession() in the lobby", err, models.SessionLobby)
+
+	g.start(t)
+	if state := g.stored(t); state != models.SessionInProgress {
+		t.Fatalf("started game is %s, want in_progress", state)
+	}
+	_, err = g.service.StartQuiz(code, g.host)
+	wantState(t, "second StartQuiz()", err, models.SessionInProgress)
+	_, err = g.service.ResumeSession(code, g.host)
+	wantState(t, "ResumeSession() in progress", err, models.SessionInProgress)
+	_, err = g.service.ArchiveSession(code, g.host)
+	wantState(t, "ArchiveSession() in progress", err, models.SessionInProgress)
+
+	if _, err := g.service.PauseSession(code, g.host); err != nil {
+		t.Fatalf("PauseSession() = %v", err)
+	}
+	_, err = g.answer(g.alice, 0, "Paris", "")
+	wantState(t, "answer while paused", err, models.SessionPaused)
+	if _, err := g.service.ResumeSession(code, g.host); err != nil {
+		t.Fatalf("ResumeSession() = %v", err)
+	}
+	// The question is still on the clock after the pause.
+	if _, err := g.answer(g.alice, 0, "Paris", ""); err != nil {
+		t.Errorf("answer after resuming = %v", err)
+	}
+
+	if _, err := g.service.EndSession(code, g.host); err != nil {
+		t.Fatalf("EndSession() = %v", err)
+	}
+	_, err = g.answer(g.bob, 0, "Paris", "")
+	wantState(t, "answer after the end", err, models.SessionFinished)
+	_, err = g.service.EndSession(code, g.host)
+	wantState(t, "second EndSession()", err, models.SessionFinished)
+	_, err = g.service.JoinQuiz(code, g.bob)
+	wantState(t, "JoinQuiz() after the end", err, models.SessionFinished)
+
+	if _, err := g.service.ArchiveSession(code, g.host); err != nil {
+		t.Fatalf("ArchiveSession() = %v", err)
+	}
+	if state := g.stored(t); state != models.SessionArchived {
+		t.Errorf("archived game is %s, want archived", state)
+	}
+}
+
+func TestTransitionFromStaleState(t *testing.T) {
+	g := newGame(t)
+	g.start(t)
+	// Another request loaded the session before it was paused.
+	stale, err := g.service.repo.GetSessionByID(g.session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.service.PauseSession(g.session.JoinCode, g.host); err != nil {
+		t.Fatalf("PauseSession() = %v", err)
+	}
+
+	err = g.service.transition(stale, "end the quiz", models.SessionFinished)
+	wantState(t, "transition() from a stale state", err, models.SessionPaused)
+	if stale.State != models.SessionInProgress || stale.EndedAt != nil {
+		t.Errorf("stale session = %s, ended %v, want it left as loaded", stale.State, stale.EndedAt)
+	}
+	if state := g.stored(t); state != models.SessionPaused {
+		t.Errorf("stored session is %s, want paused", state)
+	}
+}
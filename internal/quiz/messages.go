@@ -0,0 +1,168 @@
+// backend/internal/quiz/messages.go
+package quiz
+
+import (
+	"quiz-system/internal/models"
+	"quiz-system/pkg/websocket"
+	"time"
+)
+
+// QuestionMessage is a question as sent to a player, or to the host with its
+// correct answer. Deadline is when answers close, in Unix milliseconds. Revive
+// marks a revive round of a survival game.
+type QuestionMessage struct {
+	Question  models.QuestionDTO `json:"question"`
+	Index     int                `json:"index"`
+	Total     int                `json:"total"`
+	QuizID    uint               `json:"quizId"`
+	SessionID uint               `json:"sessionId"`
+	Deadline  int64              `json:"deadline"`
+	Revive    bool               `json:"revive,omitempty"`
+}
+
+// SessionStateMessage announces a session moving to another state.
+type SessionStateMessage struct {
+	SessionID uint                `json:"sessionId"`
+	State     models.SessionState `json:"state"`
+	Previous  models.SessionState `json:"previous"`
+}
+
+// SelfPacedStartMessage starts a self-paced session. Deadline is when its
+// overall time limit runs out, or null for none.
+type SelfPacedStartMessage struct {
+	SessionID uint   `json:"sessionId"`
+	Total     int    `json:"total"`
+	Deadline  *int64 `json:"deadline"`
+}
+
+// QuizResumedMessage announces that a paused session goes on. Players on a
+// question also get its ID and when it now expires.
+type QuizResumedMessage struct {
+	SessionID  uint   `json:"sessionId"`
+	Deadline   *int64 `json:"deadline"`
+	QuestionID uint   `json:"questionId,omitempty"`
+	ExpiresAt  int64  `json:"expiresAt,omitempty"`
+}
+
+// QuestionSkippedMessage announces that the host skipped a question.
+type QuestionSkippedMessage struct {
+	SessionID  uint `json:"sessionId"`
+	QuestionID uint `json:"questionId"`
+	Index      int  `json:"index"`
+}
+
+// QuestionTimeoutMessage tells a player their time on a question ran out.
+type QuestionTimeoutMessage struct {
+	QuestionID uint `json:"questionId"`
+	Index      int  `json:"index"`
+}
+
+// QuizEndWaitMessage tells a self-paced player who finished to wait for the others.
+type QuizEndWaitMessage struct {
+	Message string `json:"message"`
+}
+
+// AnswerCountMessage tells the host and spectators how many players answered
+// a question.
+type AnswerCountMessage struct {
+	QuestionID   uint  `json:"questionId"`
+	Responded    int64 `json:"responded"`
+	Participants int   `json:"participants"`
+}
+
+// QuestionResultsMessage shows the results of a question. Options is set for
+// choice questions and Teams for sessions played in teams; You is each
+// player's own outcome.
+type QuestionResultsMessage struct {
+	SessionID     uint                          `json:"sessionId"`
+	QuestionID    uint                          `json:"questionId"`
+	Index         int                           `json:"index"`
+	Total         int                           `json:"total"`
+	CorrectAnswer string                        `json:"correctAnswer"`
+	Summary       AnswerSummary                 `json:"summary"`
+	Top           []LeaderboardStanding         `json:"top"`
+	Options       []OptionResult                `json:"options,omitempty"`
+	Teams         []models.TeamLeaderboardEntry `json:"teams,omitempty"`
+	You           *PlayerResult                 `json:"you,omitempty"`
+}
+
+// LeaderboardUpdateMessage is the live leaderboard. Teams ranks the teams of
+// a session played in teams; You is the player's own standing.
+type LeaderboardUpdateMessage struct {
+	SessionID uint                          `json:"sessionId"`
+	Top       []LeaderboardStanding         `json:"top"`
+	Players   int                           `json:"players"`
+	Teams     []models.TeamLeaderboardEntry `json:"teams,omitempty"`
+	You       *LeaderboardStanding          `json:"you,omitempty"`
+}
+
+// TeamsMessage lists the teams of a session and their players.
+type TeamsMessage struct {
+	SessionID uint         `json:"sessionId"`
+	Teams     []TeamRoster `json:"teams"`
+}
+
+// ResumeMessage is the state of a session for a player who (re)connected.
+// The question fields are set while a quiz is running.
+type ResumeMessage struct {
+	SessionID  uint                 `json:"sessionId"`
+	State      models.SessionState  `json:"state"`
+	Pacing     models.PacingMode    `json:"pacing"`
+	Phase      models.QuestionPhase `json:"phase"`
+	Deadline   *int64               `json:"deadline,omitempty"`
+	You        *LeaderboardStanding `json:"you,omitempty"`
+	Total      int                  `json:"total,omitempty"`
+	Question   *QuestionMessage     `json:"question,omitempty"`
+	Remaining  *int64               `json:"remaining,omitempty"` // milliseconds left on the question
+	Answered   *bool                `json:"answered,omitempty"`
+	Result     *PlayerResult        `json:"result,omitempty"`
+	Finished   bool                 `json:"finished,omitempty"`
+	Eliminated bool                 `json:"eliminated,omitempty"` // out of a survival game
+}
+
+// EliminationMessage tells the room of a survival game who a question knocked
+// out and who it brought back, and how many players are left.
+type EliminationMessage struct {
+	SessionID  uint             `json:"sessionId"`
+	QuestionID uint             `json:"questionId"`
+	Index      int              `json:"index"`
+	Eliminated []SurvivalPlayer `json:"eliminated"`
+	Revived    []SurvivalPlayer `json:"revived"`
+	Remaining  int              `json:"remaining"`
+}
+
+// SurvivalOverMessage ends a survival game with the players still in it.
+type SurvivalOverMessage struct {
+	SessionID uint             `json:"sessionId"`
+	Survivors []SurvivalPlayer `json:"survivors"`
+}
+
+// Messages lists the messages the quiz service sends, for the protocol schema.
+var Messages = []websocket.MessageSpec{
+	{Type: "question", Description: "A question to answer; hosts also get its correct answer.", Data: QuestionMessage{}},
+	{Type: "session_state", Description: "The session moved to another state.", Data: SessionStateMessage{}},
+	{Type: "self_paced_start", Description: "A self-paced session started.", Data: SelfPacedStartMessage{}},
+	{Type: "quiz_resumed", Description: "A paused session goes on.", Data: QuizResumedMessage{}},
+	{Type: "question_skipped", Description: "The host skipped a question.", Data: QuestionSkippedMessage{}},
+	{Type: "question_timeout", Description: "The player's time on a question ran out.", Data: QuestionTimeoutMessage{}},
+	{Type: "quiz_end_wait", Description: "The player finished and waits for the others.", Data: QuizEndWaitMessage{}},
+	{Type: "answer_count", Description: "How many players answered (host and spectators).", Data: AnswerCountMessage{}},
+	{Type: "question_results", Description: "The results of a question.", Data: QuestionResultsMessage{}},
+	{Type: "leaderboard_update", Description: "The live leaderboard.", Data: LeaderboardUpdateMessage{}},
+	{Type: "resume", Description: "The player's state after (re)connecting.", Data: ResumeMessage{}},
+	{Type: "teams", Description: "The teams and their players changed.", Data: TeamsMessage{}},
+	{Type: "elimination", Description: "Who a question of a survival game knocked out and brought back.", Data: EliminationMessage{}},
+	{Type: "survival_over", Description: "A survival game ended; the survivors won.", Data: SurvivalOverMessage{}},
+	{Type: "final_leaderboard", Description: "The final scores.", Data: []models.LeaderboardEntry{}},
+	{Type: "final_team_leaderboard", Description: "The final team scores of a session played in teams.", Data: []models.TeamLeaderboardEntry{}},
+	{Type: "quiz_end", Description: "A host-paced session played its last question.", Data: nil},
+}
+
+// unixMilli returns t in Unix milliseconds, or nil.
+func unixMilli(t *time.Time) *int64 {
+	if t == nil {
+		return nil
+	}
+	ms := t.UnixMilli()
+	return &ms
+}
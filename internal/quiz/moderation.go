@@ -0,0 +1,65 @@
+// backend/internal/quiz/moderation.go
+package quiz
+
+import (
+	"fmt"
+	"log"
+	"quiz-system/internal/models"
+	"quiz-system/pkg/websocket"
+)
+
+// KickParticipant removes a player from a session and closes their
+// connection. They may join again.
+func (s *Service) KickParticipant(code string, hostID, userID uint, reason string) error {
+	session, err := s.authorizeHost(code, hostID)
+	if err != nil {
+		return err
+	}
+	if userID == session.HostID {
+		return fmt.Errorf("%w: the host cannot remove themselves", ErrInvalidInput)
+	}
+
+	log.Printf("Host %d kicked user %d from session %s: %s", hostID, userID, session.JoinCode, reason)
+	s.disconnect(session, userID, websocket.CloseKicked, reason)
+	return s.removeParticipant(session, userID)
+}
+
+// BanParticipant removes a player from a session, closes their connection
+// and keeps them from joining it again. Users who are not in the session can
+// be banned too.
+func (s *Service) BanParticipant(code string, hostID, userID uint, reason string) error {
+	session, err := s.authorizeHost(code, hostID)
+	if err != nil {
+		return err
+	}
+	if userID == session.HostID {
+		return fmt.Errorf("%w: the host cannot ban themselves", ErrInvalidInput)
+	}
+
+	ban := &models.SessionBan{
+		SessionID: session.ID,
+		UserID:    userID,
+		BannedBy:  hostID,
+		Reason:    reason,
+	}
+	if err := s.repo.BanUser(ban); err != nil {
+		return err
+	}
+
+	log.Printf("Host %d banned user %d from session %s: %s", hostID, userID, session.JoinCode, reason)
+	s.disconnect(session, userID, websocket.CloseBanned, reason)
+	return s.removeParticipant(session, userID)
+}
+
+// IsBanned reports whether the user is banned from the session.
+func (s *Service) IsBanned(sessionID, userID uint) (bool, error) {
+	return s.repo.IsBanned(sessionID, userID)
+}
+
+// disconnect tells a removed player why and closes their connection.
+func (s *Service) disconnect(session *models.QuizSession, userID uint, closeCode int, reason string) {
+	if s.wsHub == nil {
+		return
+	}
+	s.wsHub.DisconnectUser(session.JoinCode, userID, closeCode, reason)
+}
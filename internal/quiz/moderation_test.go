@@ -0,0 +1,116 @@
+// backend/internal/quiz/moderation_test.go
+package quiz
+
+import (
+	"errors"
+	"quiz-system/internal/models"
+	"testing"
+)
+
+// participates reports whether the user is in the game's session.
+func (g *game) participates(t *testing.T, userID uint) bool {
+	t.Helper()
+	ids, err := g.service.ParticipantIDs(g.session.JoinCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func TestKickParticipant(t *testing.T) {
+	g := newGame(t)
+	code := g.session.JoinCode
+	g.start(t)
+	if _, err := g.answer(g.alice, 0, "Paris", ""); err != nil {
+		t.Fatalf("answer = %v", err)
+	}
+
+	if err := g.service.KickParticipant(code, g.bob, g.alice, "spam"); !errors.Is(err, ErrNotHost) {
+		t.Errorf("kick by a player = %v, want ErrNotHost", err)
+	}
+	if err := g.service.KickParticipant(code, g.host, g.host, ""); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("host kicking themselves = %v, want ErrInvalidInput", err)
+	}
+	if !g.participates(t, g.alice) {
+		t.Fatal("a refused kick removed the player")
+	}
+
+	for _, userID := range []uint{g.alice, g.bob} {
+		if err := g.service.KickParticipant(code, g.host, userID, "spam"); err != nil {
+			t.Fatalf("KickParticipant(%d) = %v", userID, err)
+		}
+		if g.participates(t, userID) {
+			t.Errorf("user %d is still a participant", userID)
+		}
+	}
+	// Bob was still on the clock; their timer goes with them.
+	if _, ok, _ := g.service.clock.peek(code, g.bob); ok {
+		t.Error("the kicked player's question timer is still running")
+	}
+	var progress int64
+	g.db.Model(&models.UserQuizProgress{}).Where("session_id = ? AND user_id = ?", g.session.ID, g.alice).Count(&progress)
+	if progress != 0 {
+		t.Error("the kicked player's progress was kept")
+	}
+	leaderboard, err := g.service.GetLeaderboard(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range leaderboard {
+		if entry.UserID == g.alice {
+			t.Error("the kicked player is still on the leaderboard")
+		}
+	}
+
+	// A kicked player may come back.
+	if _, err := g.service.JoinQuiz(code, g.alice); err != nil {
+		t.Errorf("JoinQuiz() after a kick = %v", err)
+	}
+}
+
+func TestBanParticipant(t *testing.T) {
+	g := newGame(t)
+	code := g.session.JoinCode
+
+	if err := g.service.BanParticipant(code, g.bob, g.alice, "cheating"); !errors.Is(err, ErrNotHost) {
+		t.Errorf("ban by a player = %v, want ErrNotHost", err)
+	}
+	if err := g.service.BanParticipant(code, g.host, g.host, ""); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("host banning themselves = %v, want ErrInvalidInput", err)
+	}
+
+	if err := g.service.BanParticipant(code, g.host, g.alice, "cheating"); err != nil {
+		t.Fatalf("BanParticipant() = %v", err)
+	}
+	if g.participates(t, g.alice) {
+		t.Error("the banned player is still a participant")
+	}
+	if _, err := g.service.JoinQuiz(code, g.alice); !errors.Is(err, ErrBanned) {
+		t.Errorf("JoinQuiz() after a ban = %v, want ErrBanned", err)
+	}
+	if g.participates(t, g.alice) {
+		t.Error("the banned player joined again")
+	}
+
+	// Users who never joined can be banned ahead of time.
+	const stranger = 999
+	if err := g.service.BanParticipant(code, g.host, stranger, ""); err != nil {
+		t.Fatalf("BanParticipant() of a stranger = %v", err)
+	}
+	if banned, err := g.service.IsBanned(g.session.ID, stranger); err != nil || !banned {
+		t.Errorf("IsBanned() = %v, %v, want true", banned, err)
+	}
+
+	// The ban holds for this session only.
+	if banned, err := g.service.IsBanned(g.session.ID+1, g.alice); err != nil || banned {
+		t.Errorf("IsBanned() in another session = %v, %v, want false", banned, err)
+	}
+	if !g.participates(t, g.bob) {
+		t.Error("banning others removed bob")
+	}
+}
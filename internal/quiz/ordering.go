@@ -0,0 +1,63 @@
+// backend/internal/quiz/ordering.go
+package quiz
+
+import (
+	"math/rand"
+	"quiz-system/internal/models"
+)
+
+// sessionQuestions returns the quiz's questions in the order the session plays
+// them. Progress indexes always refer to this order. Questions come from the
+// repository ordered by Position; when the session shuffles, the permutation
+// is derived from its stored seed so it is the same for every player and can
+// be reproduced later.
+func (s *Service) sessionQuestions(session *models.QuizSession) ([]models.Question, error) {
+	questions, err := s.repo.GetQuizQuestions(session.QuizID)
+	if err != nil {
+		return nil, err
+	}
+	return shuffleQuestions(session, questions), nil
+}
+
+func shuffleQuestions(session *models.QuizSession, questions []models.Question) []models.Question {
+	if session.ShuffleQuestions {
+		rng := rand.New(rand.NewSource(session.ShuffleSeed))
+		rng.Shuffle(len(questions), func(i, j int) {
+			questions[i], questions[j] = questions[j], questions[i]
+		})
+	}
+	for i := range questions {
+		// Ordering questions are always shuffled: their options are stored
+		// in the correct sequence.
+		if session.ShuffleOptions || questions[i].QuestionKind() == models.QuestionOrdering {
+			shuffleOptions(&questions[i], session.ShuffleSeed)
+		}
+	}
+	return questions
+}
+
+// shuffleOptions puts a question's options in an order derived from the seed.
+// Seeding per question keeps each option order independent of where the
+// question landed in the question order. The options are copied, so a
+// question shared with the cache is left alone. An ordering question never
+// keeps its stored order, which is its answer.
+func shuffleOptions(question *models.Question, seed int64) {
+	options := append([]models.Option(nil), question.Options...)
+	rng := rand.New(rand.NewSource(seed ^ int64(question.ID)))
+	rng.Shuffle(len(options), func(a, b int) {
+		options[a], options[b] = options[b], options[a]
+	})
+	if question.QuestionKind() == models.QuestionOrdering && len(options) > 1 && sameOrder(options, question.Options) {
+		options = append(options[1:], options[0])
+	}
+	question.Options = options
+}
+
+func sameOrder(a, b []models.Option) bool {
+	for i := range a {
+		if a[i].ID != b[i].ID {
+			return false
+		}
+	}
+	return true
+}
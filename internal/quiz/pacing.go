@@ -0,0 +1,163 @@
+// backend/internal/quiz/pacing.go
+package quiz
+
+import (
+	"fmt"
+	"log"
+	"quiz-system/internal/models"
+	"time"
+)
+
+// validatePacing checks a quiz's pacing mode before it is saved.
+func validatePacing(mode models.PacingMode) error {
+	switch mode {
+	case "", models.PacingHost, models.PacingSelf:
+		return nil
+	}
+	return fmt.Errorf("%w: unknown pacing mode %q", ErrInvalidInput, mode)
+}
+
+// startHostPaced sends every player the first question at once.
+func (s *Service) startHostPaced(session *models.QuizSession, questions []models.Question) error {
+	session.CurrentIndex = 0
+	session.Phase = models.PhaseQuestion
+	if err := s.repo.UpdateSession(session); err != nil {
+		return err
+	}
+
+	expiresAt := s.armRoom(session, questions[0], 0, time.Now())
+
+	log.Printf("Broadcasting first question of session %s", session.JoinCode)
+	s.broadcastQuestion(session, questions[0], 0, len(questions), expiresAt)
+	return nil
+}
+
+// startSelfPaced sends each player the first question and starts the clock on
+// the session's overall time limit, if it has one.
+func (s *Service) startSelfPaced(session *models.QuizSession, questions []models.Question) error {
+	if session.SelfPacedLimit > 0 {
+		deadline := time.Now().Add(time.Duration(session.SelfPacedLimit) * time.Second)
+		session.Deadline = &deadline
+		if err := s.repo.UpdateSession(session); err != nil {
+			return err
+		}
+		s.armSessionDeadline(session)
+	}
+
+	s.wsHub.BroadcastMessage(session.JoinCode, "self_paced_start", SelfPacedStartMessage{
+		SessionID: session.ID,
+		Total:     len(questions),
+		Deadline:  unixMilli(session.Deadline),
+	})
+
+	userIDs, err := s.repo.GetParticipantIDs(session.ID)
+	if err != nil {
+		return err
+	}
+	log.Printf("Sending first question of self-paced session %s to %d players", session.JoinCode, len(userIDs))
+	for _, userID := range userIDs {
+		s.sendNextQuestion(session, userID, 0)
+	}
+	return nil
+}
+
+// armSessionDeadline wakes this instance when the overall time limit of a
+// self-paced session runs out. The deadline itself is stored on the session,
+// where pausing and resuming the quiz on any instance moves it.
+func (s *Service) armSessionDeadline(session *models.QuizSession) {
+	sessionID, quizCode := session.ID, session.JoinCode
+	time.AfterFunc(time.Until(*session.Deadline), func() {
+		s.inRoom(quizCode, func() {
+			s.handleSessionDeadline(sessionID)
+		})
+	})
+}
+
+// handleSessionDeadline ends a self-paced session whose overall time limit ran
+// out. A paused session is left alone, as resuming it sets a new deadline; a
+// deadline that was moved meanwhile is waited for again.
+func (s *Service) handleSessionDeadline(sessionID uint) {
+	session, err := s.repo.GetSessionByID(sessionID)
+	if err != nil {
+		log.Printf("Error loading session %d at its deadline: %v", sessionID, err)
+		return
+	}
+	if session.State != models.SessionInProgress || session.Deadline == nil {
+		return
+	}
+	if time.Until(*session.Deadline) > 0 {
+		s.armSessionDeadline(session)
+		return
+	}
+	log.Printf("Time is up for self-paced session %s", session.JoinCode)
+	if err := s.endSession(session); err != nil {
+		log.Printf("Session %s not ended at its deadline: %v", session.JoinCode, err)
+	}
+}
+
+// playersStillPlaying counts the participants of a self-paced session who
+// have not gone past its last question. Players the host removed are no
+// longer participants, and players out of a survival game have nothing left
+// to answer, so neither keeps the session going.
+func (s *Service) playersStillPlaying(session *models.QuizSession, totalQuestions int) (int, error) {
+	participants, err := s.repo.GetParticipantIDs(session.ID)
+	if err != nil {
+		return 0, err
+	}
+	done, err := s.repo.GetFinishedPlayerIDs(session.ID, totalQuestions)
+	if err != nil {
+		return 0, err
+	}
+	if session.Survival.Enabled {
+		eliminated, err := s.repo.GetEliminatedIDs(session.ID)
+		if err != nil {
+			return 0, err
+		}
+		done = append(done, eliminated...)
+	}
+
+	finished := make(map[uint]bool, len(done))
+	for _, userID := range done {
+		finished[userID] = true
+	}
+	playing := 0
+	for _, userID := range participants {
+		if !finished[userID] {
+			playing++
+		}
+	}
+	return playing, nil
+}
+
+// afterResponse moves play on once a player's answer to, or timeout on, the
+// question at index is recorded. Self-paced players get their next question
+// straight away; in host-paced play the host is told how many have answered.
+func (s *Service) afterResponse(session *models.QuizSession, userID uint, questionID uint, index int) {
+	if session.IsSelfPaced() {
+		s.sendNextQuestion(session, userID, index+1)
+		return
+	}
+	s.reportAnswerCount(session, questionID)
+}
+
+// reportAnswerCount tells the host and the spectator displays how many players
+// have responded to a question.
+func (s *Service) reportAnswerCount(session *models.QuizSession, questionID uint) {
+	responded, err := s.repo.GetUniqueResponseCountForQuestion(session.ID, questionID)
+	if err != nil {
+		log.Printf("Error counting responses to question %d: %v", questionID, err)
+		return
+	}
+	participants, err := s.repo.GetParticipantIDs(session.ID)
+	if err != nil {
+		log.Printf("Error getting participants of session %s: %v", session.JoinCode, err)
+		return
+	}
+	count := AnswerCountMessage{
+		QuestionID:   questionID,
+		Responded:    responded,
+		Participants: len(s.contenders(session, session.CurrentIndex, participants)),
+	}
+	s.wsHub.SendMessageToHost(session.HostID, "answer_count", count)
+	s.wsHub.SendToSpectators(session.JoinCode, "answer_count", count)
+}
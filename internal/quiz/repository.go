@@ -3,11 +3,11 @@ package quiz
 
 import (
 	"errors"
-	"fmt"
 	"log"
 	"quiz-system/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository struct {
@@ -49,36 +49,71 @@ func (r *Repository) UpdateQuiz(quiz *models.Quiz) error {
     return nil
 }
 
-func (r *Repository) GetUserQuestionIndex(userID, quizID uint) (int, error) {
-	var progress models.UserQuizProgress
-	err := r.db.Where("user_id = ? AND quiz_id = ?", userID, quizID).First(&progress).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			// Record not found; create a new one with NextIndex 0
-			progress = models.UserQuizProgress{
-				UserID:    userID,
-				QuizID:    quizID,
-				NextIndex: 0,
-			}
-			if createErr := r.db.Create(&progress).Error; createErr != nil {
-				return 0, createErr
-			}
-			return 0, nil
+// GetUserProgress returns the user's progress through a session, creating it
+// at the first question if they have none yet.
+func (r *Repository) GetUserProgress(userID uint, session *models.QuizSession) (*models.UserQuizProgress, error) {
+	return findUserProgress(r.db, userID, session, false)
+}
+
+// RecordResponse saves a response and advances the user's progress in one
+// transaction. The progress row stays locked (SELECT ... FOR UPDATE) while
+// apply scores the response and moves the progress on, so concurrent
+// submissions by the same user are handled one after the other. Nothing is
+// saved if apply returns an error.
+func (r *Repository) RecordResponse(session *models.QuizSession, response *models.UserQuizResponse, apply func(progress *models.UserQuizProgress) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		progress, err := findUserProgress(tx, response.UserID, session, true)
+		if err != nil {
+			return err
 		}
-		return 0, err
-	}
-	return progress.NextIndex, nil
+		if err := apply(progress); err != nil {
+			return err
+		}
+		if err := tx.Create(response).Error; err != nil {
+			return err
+		}
+		return tx.Model(progress).Updates(map[string]interface{}{
+			"next_index":    progress.NextIndex,
+			"streak":        progress.Streak,
+			"eliminated":    progress.Eliminated,
+			"eliminated_on": progress.EliminatedOn,
+			"revived_on":    progress.RevivedOn,
+		}).Error
+	})
 }
 
-// UpdateUserQuestionIndex updates the next question index for a given user and quiz.
-func (r *Repository) UpdateUserQuestionIndex(userID, quizID uint, newIndex int) error {
-	var progress models.UserQuizProgress
-	err := r.db.Where("user_id = ? AND quiz_id = ?", userID, quizID).First(&progress).Error
-	if err != nil {
-		return err
+// findUserProgress loads the user's progress through a session, creating it at
+// the first question if they have none yet. With lock set the row stays locked
+// until db's transaction ends.
+func findUserProgress(db *gorm.DB, userID uint, session *models.QuizSession, lock bool) (*models.UserQuizProgress, error) {
+	find := func() (*models.UserQuizProgress, error) {
+		var progress models.UserQuizProgress
+		query := db.Where("user_id = ? AND session_id = ?", userID, session.ID)
+		if lock {
+			query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		if err := query.First(&progress).Error; err != nil {
+			return nil, err
+		}
+		return &progress, nil
 	}
-	progress.NextIndex = newIndex
-	return r.db.Save(&progress).Error
+
+	progress, err := find()
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return progress, err
+	}
+	// Record not found; create a new one with NextIndex 0. Two first answers
+	// may race to create it, and the unique index keeps only one.
+	created := models.UserQuizProgress{
+		UserID:    userID,
+		QuizID:    session.QuizID,
+		SessionID: session.ID,
+		NextIndex: 0,
+	}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&created).Error; err != nil {
+		return nil, err
+	}
+	return find()
 }
 
 // In repository.go
@@ -86,7 +121,10 @@ func (r *Repository) GetQuizQuestions(quizID uint) ([]models.Question, error) {
     var questions []models.Question
     
     err := r.db.Where("quiz_id = ? AND deleted_at IS NULL", quizID).
-        Preload("Options", "deleted_at IS NULL").
+        Preload("Options", func(db *gorm.DB) *gorm.DB {
+            return db.Where("deleted_at IS NULL").Order("position, id")
+        }).
+        Order("position, id").
         Find(&questions).Error
     
     if err != nil {
@@ -99,36 +137,6 @@ func (r *Repository) GetQuizQuestions(quizID uint) ([]models.Question, error) {
     return questions, nil
 }
 
-func (r *Repository) VerifyQuizData(quizID uint) error {
-    // Check questions
-    var questionCount int64
-    if err := r.db.Model(&models.Question{}).
-        Where("quiz_id = ?", quizID).
-        Count(&questionCount).Error; err != nil {
-        return err
-    }
-    log.Printf("Found %d questions for quiz %d", questionCount, quizID)
-
-    // Check options for each question
-    var questions []models.Question
-    err := r.db.Where("quiz_id = ?", quizID).Find(&questions).Error
-    if err != nil {
-        return err
-    }
-
-    for _, q := range questions {
-        var optionCount int64
-        if err := r.db.Model(&models.Option{}).
-            Where("question_id = ?", q.ID).
-            Count(&optionCount).Error; err != nil {
-            return err
-        }
-        log.Printf("Question %d has %d options", q.ID, optionCount)
-    }
-
-    return nil
-}
-
 func (r *Repository) GetQuizzesByCreator(userID uint) ([]models.Quiz, error) {
     var quizzes []models.Quiz
     err := r.db.Where("creator_id = ?", userID).Find(&quizzes).Error
@@ -141,7 +149,12 @@ func (r *Repository) GetQuizzesByCreator(userID uint) ([]models.Quiz, error) {
 
 func (r *Repository) GetQuizByCode(code string) (*models.Quiz, error) {
     var quiz models.Quiz
-    err := r.db.Preload("Questions.Options").
+    err := r.db.Preload("Questions", func(db *gorm.DB) *gorm.DB {
+        return db.Order("position, id")
+    }).
+        Preload("Questions.Options", func(db *gorm.DB) *gorm.DB {
+            return db.Order("position, id")
+        }).
         Where("quiz_code = ?", code).
         First(&quiz).Error
 
@@ -155,7 +168,9 @@ func (r *Repository) GetQuizByCode(code string) (*models.Quiz, error) {
 
 func (r *Repository) GetQuestion(questionID uint) (*models.Question, error) {
     var question models.Question
-    err := r.db.Preload("Options").First(&question, questionID).Error
+    err := r.db.Preload("Options", func(db *gorm.DB) *gorm.DB {
+        return db.Order("position, id")
+    }).First(&question, questionID).Error
     if err != nil {
         log.Printf("Error getting question %d: %v", questionID, err)
         return nil, err
@@ -163,27 +178,54 @@ func (r *Repository) GetQuestion(questionID uint) (*models.Question, error) {
     return &question, nil
 }
 
-func (r *Repository) SaveResponse(response *models.UserQuizResponse) error {
-    return r.db.Create(response).Error
+// HasResponse reports whether the user already answered the question in the session.
+func (r *Repository) HasResponse(sessionID, userID, questionID uint) (bool, error) {
+    var count int64
+    err := r.db.Model(&models.UserQuizResponse{}).
+        Where("session_id = ? AND user_id = ? AND question_id = ?", sessionID, userID, questionID).
+        Count(&count).Error
+    return count > 0, err
+}
+
+// GetResponseByIdempotencyKey finds the response a user submitted with the given key.
+func (r *Repository) GetResponseByIdempotencyKey(sessionID, userID uint, key string) (*models.UserQuizResponse, error) {
+    var response models.UserQuizResponse
+    err := r.db.Where("session_id = ? AND user_id = ? AND idempotency_key = ?", sessionID, userID, key).
+        First(&response).Error
+    if err != nil {
+        return nil, err
+    }
+    return &response, nil
 }
 
-func (r *Repository) AddParticipant(quizID, userID uint) error {
+func (r *Repository) AddParticipant(session *models.QuizSession, userID uint) error {
     participant := &models.QuizParticipant{
-        QuizID: quizID,
-        UserID: userID,
+        QuizID:    session.QuizID,
+        SessionID: session.ID,
+        UserID:    userID,
     }
-    err := r.db.Create(participant).Error
+    err := r.db.Where("session_id = ? AND user_id = ?", session.ID, userID).
+        FirstOrCreate(participant).Error
     if err != nil {
-        log.Printf("Error adding participant %d to quiz %d: %v", userID, quizID, err)
+        log.Printf("Error adding participant %d to session %d: %v", userID, session.ID, err)
         return err
     }
-    log.Printf("Added participant %d to quiz %d", userID, quizID)
+    log.Printf("Added participant %d to session %d", userID, session.ID)
     return nil
 }
 
+// GetParticipantIDs returns the users who have joined the session.
+func (r *Repository) GetParticipantIDs(sessionID uint) ([]uint, error) {
+    var userIDs []uint
+    err := r.db.Model(&models.QuizParticipant{}).
+        Where("session_id = ? AND deleted_at IS NULL", sessionID).
+        Distinct().
+        Pluck("user_id", &userIDs).Error
+    return userIDs, err
+}
 
-func (r *Repository) RemoveParticipant(quizID, userID uint) error {
-    result := r.db.Where("quiz_id = ? AND user_id = ?", quizID, userID).
+func (r *Repository) RemoveParticipant(sessionID, userID uint) error {
+    result := r.db.Where("session_id = ? AND user_id = ?", sessionID, userID).
         Delete(&models.QuizParticipant{})
     
     if result.Error != nil {
@@ -193,29 +235,177 @@ func (r *Repository) RemoveParticipant(quizID, userID uint) error {
     return nil
 }
 
-func (r *Repository) ClearUserProgress(quizID, userID uint) error {
-    result := r.db.Where("quiz_id = ? AND user_id = ?", quizID, userID).
+// CreateTeam adds a team to a session.
+func (r *Repository) CreateTeam(team *models.Team) error {
+    err := r.db.Create(team).Error
+    if err != nil {
+        log.Printf("Error creating team %q in session %d: %v", team.Name, team.SessionID, err)
+        return err
+    }
+    log.Printf("Created team %d (%s) in session %d", team.ID, team.Name, team.SessionID)
+    return nil
+}
+
+// GetTeams returns the teams of a session in the order they were created.
+func (r *Repository) GetTeams(sessionID uint) ([]models.Team, error) {
+    var teams []models.Team
+    err := r.db.Where("session_id = ?", sessionID).
+        Order("id").
+        Find(&teams).Error
+    return teams, err
+}
+
+// GetTeam returns a team of the session.
+func (r *Repository) GetTeam(sessionID, teamID uint) (*models.Team, error) {
+    var team models.Team
+    err := r.db.Where("session_id = ? AND id = ?", sessionID, teamID).
+        First(&team).Error
+    if err != nil {
+        return nil, err
+    }
+    return &team, nil
+}
+
+// GetTeamMembers lists the players of a session with the team each is in.
+// Players who are not in a team have a nil TeamID.
+func (r *Repository) GetTeamMembers(sessionID uint) ([]models.TeamMember, error) {
+    var members []models.TeamMember
+    err := r.db.Table("quiz_participants qp").
+        Select("qp.user_id, u.username, qp.team_id").
+        Joins("JOIN users u ON u.id = qp.user_id").
+        Where("qp.session_id = ? AND qp.deleted_at IS NULL", sessionID).
+        Order("qp.id").
+        Scan(&members).Error
+    return members, err
+}
+
+// SetParticipantTeam puts a player in a team, or out of every team for a nil
+// teamID. It fails with gorm.ErrRecordNotFound if the user has not joined.
+func (r *Repository) SetParticipantTeam(sessionID, userID uint, teamID *uint) error {
+    result := r.db.Model(&models.QuizParticipant{}).
+        Where("session_id = ? AND user_id = ?", sessionID, userID).
+        Update("team_id", teamID)
+    if result.Error != nil {
+        return result.Error
+    }
+    if result.RowsAffected == 0 {
+        return gorm.ErrRecordNotFound
+    }
+    return nil
+}
+
+// GetEliminatedIDs returns the players who are out of a survival game.
+func (r *Repository) GetEliminatedIDs(sessionID uint) ([]uint, error) {
+    var userIDs []uint
+    err := r.db.Model(&models.UserQuizProgress{}).
+        Where("session_id = ? AND eliminated", sessionID).
+        Pluck("user_id", &userIDs).Error
+    return userIDs, err
+}
+
+// GetSurvivors returns the players of a survival game who are still in it.
+func (r *Repository) GetSurvivors(sessionID uint) ([]SurvivalPlayer, error) {
+    var players []SurvivalPlayer
+    err := r.db.Table("quiz_participants qp").
+        Select("qp.user_id, u.username").
+        Joins("JOIN users u ON u.id = qp.user_id").
+        Joins("LEFT JOIN user_quiz_progress p ON p.session_id = qp.session_id AND p.user_id = qp.user_id").
+        Where("qp.session_id = ? AND qp.deleted_at IS NULL", sessionID).
+        Where("(p.eliminated IS NULL OR NOT p.eliminated)").
+        Order("qp.id").
+        Scan(&players).Error
+    return players, err
+}
+
+// GetEliminatedOn returns the players the question at index knocked out.
+func (r *Repository) GetEliminatedOn(sessionID uint, index int) ([]SurvivalPlayer, error) {
+    return r.survivalPlayers("p.session_id = ? AND p.eliminated AND p.eliminated_on = ?", sessionID, index)
+}
+
+// GetRevivedOn returns the players the revive round at index brought back.
+func (r *Repository) GetRevivedOn(sessionID uint, index int) ([]SurvivalPlayer, error) {
+    return r.survivalPlayers("p.session_id = ? AND NOT p.eliminated AND p.revived_on = ?", sessionID, index)
+}
+
+func (r *Repository) survivalPlayers(query string, args ...interface{}) ([]SurvivalPlayer, error) {
+    var players []SurvivalPlayer
+    err := r.db.Table("user_quiz_progress p").
+        Select("p.user_id, u.username").
+        Joins("JOIN users u ON u.id = p.user_id").
+        Where(query, args...).
+        Order("p.id").
+        Scan(&players).Error
+    return players, err
+}
+
+// ReinstatePlayers puts the players the question at index knocked out back in.
+func (r *Repository) ReinstatePlayers(sessionID uint, index int) error {
+    return r.db.Model(&models.UserQuizProgress{}).
+        Where("session_id = ? AND eliminated AND eliminated_on = ?", sessionID, index).
+        Updates(map[string]interface{}{"eliminated": false, "eliminated_on": nil}).Error
+}
+
+// UnrevivePlayers takes the players the revive round at index brought back
+// out again.
+func (r *Repository) UnrevivePlayers(sessionID uint, index int) error {
+    return r.db.Model(&models.UserQuizProgress{}).
+        Where("session_id = ? AND NOT eliminated AND revived_on = ?", sessionID, index).
+        Updates(map[string]interface{}{"eliminated": true, "revived_on": nil}).Error
+}
+
+// EliminatePlayer puts a player out of a survival game without tying it to a
+// question, for players who join after it started.
+func (r *Repository) EliminatePlayer(session *models.QuizSession, userID uint) error {
+    progress, err := findUserProgress(r.db, userID, session, false)
+    if err != nil {
+        return err
+    }
+    return r.db.Model(progress).Update("eliminated", true).Error
+}
+
+// BanUser records a ban; banning a user twice keeps the first ban.
+func (r *Repository) BanUser(ban *models.SessionBan) error {
+    return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(ban).Error
+}
+
+// IsBanned reports whether the user is banned from the session.
+func (r *Repository) IsBanned(sessionID, userID uint) (bool, error) {
+    var count int64
+    err := r.db.Model(&models.SessionBan{}).
+        Where("session_id = ? AND user_id = ?", sessionID, userID).
+        Count(&count).Error
+    return count > 0, err
+}
+
+func (r *Repository) ClearUserProgress(sessionID, userID uint) error {
+    // Responses are removed for good so the player can answer again if they rejoin.
+    result := r.db.Unscoped().Where("session_id = ? AND user_id = ?", sessionID, userID).
         Delete(&models.UserQuizResponse{})
     
     if result.Error != nil {
         return result.Error
     }
-    
-    return nil
+
+    return r.db.Where("session_id = ? AND user_id = ?", sessionID, userID).
+        Delete(&models.UserQuizProgress{}).Error
 }
 
 // repository.go
-func (r *Repository) GetLeaderboard(quizID uint) ([]models.LeaderboardEntry, error) {
+func (r *Repository) GetLeaderboard(sessionID uint) ([]models.LeaderboardEntry, error) {
     var entries []models.LeaderboardEntry
     
     err := r.db.Raw(`
-        SELECT u.username, SUM(uqr.score) as total_score
+        SELECT u.id AS user_id, u.username, SUM(uqr.score) as total_score,
+            t.id AS team_id, t.name AS team_name
         FROM users u
         JOIN user_quiz_responses uqr ON u.id = uqr.user_id
-        WHERE uqr.quiz_id = ? AND uqr.deleted_at IS NULL
-        GROUP BY u.username
+        LEFT JOIN quiz_participants qp ON qp.session_id = uqr.session_id
+            AND qp.user_id = u.id AND qp.deleted_at IS NULL
+        LEFT JOIN teams t ON t.id = qp.team_id AND t.deleted_at IS NULL
+        WHERE uqr.session_id = ? AND uqr.deleted_at IS NULL
+        GROUP BY u.id, u.username, t.id, t.name
         ORDER BY total_score DESC
-    `, quizID).Scan(&entries).Error
+    `, sessionID).Scan(&entries).Error
 
     if err != nil {
         log.Printf("Error getting leaderboard: %v", err)
@@ -225,24 +415,6 @@ func (r *Repository) GetLeaderboard(quizID uint) ([]models.LeaderboardEntry, err
     return entries, nil
 }
 
-// repository.go
-func (r *Repository) GetQuestionIndex(quizID uint, questionID uint) (int, error) {
-    var questions []models.Question
-    err := r.db.Where("quiz_id = ? AND deleted_at IS NULL", quizID).
-        Order("created_at asc").
-        Find(&questions).Error
-    if err != nil {
-        return 0, err
-    }
-
-    for i, q := range questions {
-        if q.ID == questionID {
-            return i, nil
-        }
-    }
-    return 0, fmt.Errorf("question not found")
-}
-
 func (r *Repository) GetQuizByID(quizID uint) (*models.Quiz, error) {
     var quiz models.Quiz
     err := r.db.First(&quiz, quizID).Error
@@ -255,62 +427,300 @@ func (r *Repository) GetQuizByID(quizID uint) (*models.Quiz, error) {
 
 
 
-func (r *Repository) GetUniqueResponseCountForQuestion(questionID uint) (int64, error) {
+func (r *Repository) GetUniqueResponseCountForQuestion(sessionID, questionID uint) (int64, error) {
     var count int64
     err := r.db.Model(&models.UserQuizResponse{}).
-        Where("question_id = ? AND deleted_at IS NULL", questionID).
+        Where("session_id = ? AND question_id = ? AND deleted_at IS NULL", sessionID, questionID).
         Distinct("user_id").
         Count(&count).Error
     return count, err
 }
 
-func (r *Repository) GetUniqueParticipantsForQuiz(quizID uint) (int64, error) {
-    var count int64
-    err := r.db.Model(&models.UserQuizResponse{}).
-        Where("quiz_id = ? AND deleted_at IS NULL", quizID).
-        Distinct("user_id").
-        Count(&count).Error
-    return count, err
+// GetQuestionResponses returns every response to a question of the session.
+func (r *Repository) GetQuestionResponses(sessionID, questionID uint) ([]models.UserQuizResponse, error) {
+    var responses []models.UserQuizResponse
+    err := r.db.Where("session_id = ? AND question_id = ?", sessionID, questionID).
+        Find(&responses).Error
+    return responses, err
 }
-func (r *Repository) IsUserHost(quizID, userID uint) (bool, error) {
-    var quiz models.Quiz
-    err := r.db.Select("creator_id").Where("id = ?", quizID).First(&quiz).Error
+
+// DeleteQuestionResponses removes every response to a question of the
+// session for good, so the question no longer counts towards any score.
+func (r *Repository) DeleteQuestionResponses(sessionID, questionID uint) error {
+    return r.db.Unscoped().Where("session_id = ? AND question_id = ?", sessionID, questionID).
+        Delete(&models.UserQuizResponse{}).Error
+}
+
+// GetUserResponses returns every response the user gave in the session.
+func (r *Repository) GetUserResponses(sessionID, userID uint) ([]models.UserQuizResponse, error) {
+    var responses []models.UserQuizResponse
+    err := r.db.Where("session_id = ? AND user_id = ?", sessionID, userID).
+        Find(&responses).Error
+    return responses, err
+}
+
+// SetStreak stores the number of fully correct answers in a row the user
+// has given in the session.
+func (r *Repository) SetStreak(sessionID, userID uint, streak int) error {
+    return r.db.Model(&models.UserQuizProgress{}).
+        Where("session_id = ? AND user_id = ?", sessionID, userID).
+        Update("streak", streak).Error
+}
+
+// GetFinishedPlayerIDs returns the players who have gone past the last of
+// totalQuestions questions.
+func (r *Repository) GetFinishedPlayerIDs(sessionID uint, totalQuestions int) ([]uint, error) {
+    var userIDs []uint
+    err := r.db.Model(&models.UserQuizProgress{}).
+        Where("session_id = ? AND next_index >= ?", sessionID, totalQuestions).
+        Pluck("user_id", &userIDs).Error
     if err != nil {
-        return false, err
+        log.Printf("Error listing finished players: %v", err)
+        return nil, err
     }
-    return quiz.CreatorID == userID, nil
+    return userIDs, nil
 }
 
 
+// SetQuizActive flags whether the quiz has a session in progress.
+func (r *Repository) SetQuizActive(quizID uint, active bool) error {
+    return r.db.Model(&models.Quiz{}).
+        Where("id = ?", quizID).
+        Update("is_active", active).Error
+}
 
-// repository/user_quiz_progress.go
-func (r *Repository) GetFinishedCount(quizID uint, totalQuestions int) (int64, error) {
-	var count int64
-	err := r.db.Model(&models.UserQuizProgress{}).
-		Where("quiz_id = ? AND next_index >= ?", quizID, totalQuestions).
-		Count(&count).Error
-	return count, err
+func (r *Repository) CreateSession(session *models.QuizSession) error {
+    err := r.db.Create(session).Error
+    if err != nil {
+        log.Printf("Error creating session for quiz %d: %v", session.QuizID, err)
+        return err
+    }
+    log.Printf("Created session %d (%s) for quiz %d", session.ID, session.JoinCode, session.QuizID)
+    return nil
 }
 
+// UpdateSession saves the session's settings and progress. Its state is left
+// as it is in the database; only TransitionSession changes it.
+func (r *Repository) UpdateSession(session *models.QuizSession) error {
+    return r.db.Omit("Participants", "Teams", "State").Save(session).Error
+}
 
+// TransitionSession stores the session's new state, with its start and end
+// times, provided the stored state is still from. It reports false, saving
+// nothing, when another request changed the state first.
+func (r *Repository) TransitionSession(session *models.QuizSession, from models.SessionState) (bool, error) {
+    result := r.db.Model(&models.QuizSession{}).
+        Where("id = ? AND state = ?", session.ID, from).
+        Updates(map[string]interface{}{
+            "state":      session.State,
+            "started_at": session.StartedAt,
+            "ended_at":   session.EndedAt,
+        })
+    return result.RowsAffected > 0, result.Error
+}
+
+func (r *Repository) GetSessionByID(sessionID uint) (*models.QuizSession, error) {
+    var session models.QuizSession
+    err := r.db.First(&session, sessionID).Error
+    if err != nil {
+        log.Printf("Error getting session %d: %v", sessionID, err)
+        return nil, err
+    }
+    return &session, nil
+}
+
+func (r *Repository) GetSessionByJoinCode(code string) (*models.QuizSession, error) {
+    var session models.QuizSession
+    err := r.db.Preload("Participants").Preload("Teams").
+        Where("join_code = ?", code).
+        First(&session).Error
+    if err != nil {
+        log.Printf("Error getting session by code %s: %v", code, err)
+        return nil, err
+    }
+    return &session, nil
+}
+
+// GetOpenSession returns the most recent session of the quiz that has not finished.
+func (r *Repository) GetOpenSession(quizID uint) (*models.QuizSession, error) {
+    var session models.QuizSession
+    err := r.db.Preload("Participants").Preload("Teams").
+        Where("quiz_id = ? AND state NOT IN ?", quizID,
+            []models.SessionState{models.SessionFinished, models.SessionArchived}).
+        Order("id desc").
+        First(&session).Error
+    if err != nil {
+        return nil, err
+    }
+    return &session, nil
+}
+
+// GetLatestSession returns the most recent session of the quiz in any state.
+func (r *Repository) GetLatestSession(quizID uint) (*models.QuizSession, error) {
+    var session models.QuizSession
+    err := r.db.Preload("Participants").Preload("Teams").
+        Where("quiz_id = ?", quizID).
+        Order("id desc").
+        First(&session).Error
+    if err != nil {
+        return nil, err
+    }
+    return &session, nil
+}
+
+func (r *Repository) GetSessionsByQuiz(quizID uint) ([]models.QuizSession, error) {
+    var sessions []models.QuizSession
+    err := r.db.Where("quiz_id = ?", quizID).
+        Order("id desc").
+        Find(&sessions).Error
+    if err != nil {
+        log.Printf("Error getting sessions for quiz %d: %v", quizID, err)
+        return nil, err
+    }
+    return sessions, nil
+}
 
-func (r *Repository) GetFinishedPlayersCount(quizID uint, totalQuestions int) (int64, error) {
+// HasLiveSession reports whether the quiz is being played right now.
+func (r *Repository) HasLiveSession(quizID uint) (bool, error) {
     var count int64
-    err := r.db.Model(&models.UserQuizProgress{}).
-        Where("quiz_id = ? AND next_index >= ?", quizID, totalQuestions).
+    err := r.db.Model(&models.QuizSession{}).
+        Where("quiz_id = ? AND state IN ?", quizID,
+            []models.SessionState{models.SessionInProgress, models.SessionPaused}).
         Count(&count).Error
+    return count > 0, err
+}
+
+func (r *Repository) UpdateQuizFields(quizID uint, fields map[string]interface{}) error {
+    return r.db.Model(&models.Quiz{}).Where("id = ?", quizID).Updates(fields).Error
+}
+
+// DeleteQuiz soft-deletes a quiz together with its questions and options.
+func (r *Repository) DeleteQuiz(quizID uint) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        questionIDs := tx.Model(&models.Question{}).Select("id").Where("quiz_id = ?", quizID)
+        if err := tx.Where("question_id IN (?)", questionIDs).Delete(&models.Option{}).Error; err != nil {
+            return err
+        }
+        if err := tx.Where("quiz_id = ?", quizID).Delete(&models.Question{}).Error; err != nil {
+            return err
+        }
+        return tx.Delete(&models.Quiz{}, quizID).Error
+    })
+}
+
+func (r *Repository) CreateQuestion(question *models.Question) error {
+    err := r.db.Create(question).Error
     if err != nil {
-        log.Printf("Error counting finished players: %v", err)
-        return 0, err
+        log.Printf("Error creating question for quiz %d: %v", question.QuizID, err)
+        return err
     }
-    return count, nil
+    return nil
 }
 
+func (r *Repository) UpdateQuestionFields(questionID uint, fields map[string]interface{}) error {
+    return r.db.Model(&models.Question{}).Where("id = ?", questionID).Updates(fields).Error
+}
 
-// repository.go
-func (r *Repository) ResetQuizProgress(quizID uint) error {
-    // Reset nextIndex to 0 for all users who participated in the quiz
-    return r.db.Model(&models.UserQuizProgress{}).
+// DeleteQuestion soft-deletes a question and its options.
+func (r *Repository) DeleteQuestion(questionID uint) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Where("question_id = ?", questionID).Delete(&models.Option{}).Error; err != nil {
+            return err
+        }
+        return tx.Delete(&models.Question{}, questionID).Error
+    })
+}
+
+// NextQuestionPosition returns the position after the quiz's last question.
+func (r *Repository) NextQuestionPosition(quizID uint) (int, error) {
+    var last *int
+    err := r.db.Model(&models.Question{}).
         Where("quiz_id = ?", quizID).
-        Update("next_index", 0).Error
+        Select("MAX(position)").
+        Scan(&last).Error
+    if err != nil || last == nil {
+        return 0, err
+    }
+    return *last + 1, nil
+}
+
+// SetQuestionPositions stores the order of the quiz's questions, ids being
+// the question IDs from first to last.
+func (r *Repository) SetQuestionPositions(quizID uint, ids []uint) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        for position, id := range ids {
+            err := tx.Model(&models.Question{}).
+                Where("id = ? AND quiz_id = ?", id, quizID).
+                Update("position", position).Error
+            if err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+}
+
+func (r *Repository) GetOption(optionID uint) (*models.Option, error) {
+    var option models.Option
+    if err := r.db.First(&option, optionID).Error; err != nil {
+        return nil, err
+    }
+    return &option, nil
+}
+
+// EditOptions changes a question's options and correct answer in one
+// transaction. The question row stays locked while edit changes the loaded
+// question in memory. Options edit drops are deleted, new ones (ID 0) are
+// created, and the rest are saved in their new order together with the
+// correct answer. Nothing is saved if edit returns an error.
+func (r *Repository) EditOptions(questionID uint, edit func(question *models.Question) error) (*models.Question, error) {
+    var question models.Question
+    err := r.db.Transaction(func(tx *gorm.DB) error {
+        err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&question, questionID).Error
+        if err != nil {
+            return err
+        }
+        err = tx.Where("question_id = ?", questionID).Order("position, id").Find(&question.Options).Error
+        if err != nil {
+            return err
+        }
+        before := optionIDs(question.Options)
+        if err := edit(&question); err != nil {
+            return err
+        }
+
+        kept := make(map[uint]bool, len(question.Options))
+        for position := range question.Options {
+            option := &question.Options[position]
+            option.QuestionID = question.ID
+            option.Position = position
+            if option.ID == 0 {
+                if err := tx.Create(option).Error; err != nil {
+                    return err
+                }
+                continue
+            }
+            kept[option.ID] = true
+            err := tx.Model(&models.Option{}).
+                Where("id = ? AND question_id = ?", option.ID, question.ID).
+                Updates(map[string]interface{}{"text": option.Text, "position": position}).Error
+            if err != nil {
+                return err
+            }
+        }
+        for _, id := range before {
+            if !kept[id] {
+                if err := tx.Delete(&models.Option{}, id).Error; err != nil {
+                    return err
+                }
+            }
+        }
+        return tx.Model(&models.Question{}).
+            Where("id = ?", question.ID).
+            Update("correct_answer", question.CorrectAnswer).Error
+    })
+    if err != nil {
+        return nil, err
+    }
+    return &question, nil
 }
@@ -0,0 +1,80 @@
+// backend/internal/quiz/repository_test.go
+package quiz
+
+import (
+	"errors"
+	"quiz-system/internal/models"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestRecordResponseIsAtomic(t *testing.T) {
+	refused := errors.New("refused")
+	tests := []struct {
+		name string
+		// existing is an answer already recorded for the question.
+		existing bool
+		apply    func(progress *models.UserQuizProgress) error
+		wantErr  error
+	}{
+		{
+			name: "refused by apply",
+			apply: func(progress *models.UserQuizProgress) error {
+				progress.NextIndex = 1
+				return refused
+			},
+			wantErr: refused,
+		},
+		{
+			name:     "answer already recorded",
+			existing: true,
+			apply: func(progress *models.UserQuizProgress) error {
+				progress.NextIndex = 1
+				progress.Streak = 3
+				return nil
+			},
+			wantErr: gorm.ErrDuplicatedKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newGame(t)
+			repo := g.service.repo
+			answer := func() *models.UserQuizResponse {
+				return &models.UserQuizResponse{
+					UserID:     g.alice,
+					QuizID:     g.session.QuizID,
+					SessionID:  g.session.ID,
+					QuestionID: g.questions[0].ID,
+					Answer:     "Paris",
+				}
+			}
+			if tt.existing {
+				if err := g.db.Create(answer()).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := repo.RecordResponse(g.session, answer(), tt.apply)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RecordResponse() = %v, want %v", err, tt.wantErr)
+			}
+			want := int64(0)
+			if tt.existing {
+				want = 1
+			}
+			if n := g.responses(t, g.alice); n != want {
+				t.Errorf("%d answers recorded, want %d", n, want)
+			}
+			progress, err := repo.GetUserProgress(g.alice, g.session)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if progress.NextIndex != 0 || progress.Streak != 0 {
+				t.Errorf("progress = index %d, streak %d, want it unchanged", progress.NextIndex, progress.Streak)
+			}
+		})
+	}
+}
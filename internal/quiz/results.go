@@ -0,0 +1,214 @@
+// backend/internal/quiz/results.go
+package quiz
+
+import (
+	"log"
+	"quiz-system/internal/models"
+	"quiz-system/pkg/websocket"
+	"strconv"
+	"strings"
+)
+
+// OptionResult is how many players picked one option of a revealed question.
+type OptionResult struct {
+	ID      uint   `json:"id"`
+	Text    string `json:"text"`
+	Count   int    `json:"count"`
+	Correct bool   `json:"correct"`
+}
+
+// AnswerSummary counts the responses to a revealed question by outcome.
+type AnswerSummary struct {
+	Correct    int `json:"correct"`
+	Partial    int `json:"partial"`
+	Incorrect  int `json:"incorrect"`
+	Unanswered int `json:"unanswered"`
+}
+
+// PlayerResult is one player's outcome on a revealed question.
+type PlayerResult struct {
+	Answer   string               `json:"answer"`
+	Credit   float64              `json:"credit"`
+	Correct  bool                 `json:"correct"`
+	Score    int                  `json:"score"`
+	Standing *LeaderboardStanding `json:"standing,omitempty"`
+}
+
+// RevealResults closes the current question of a host-paced session and shows
+// everyone its results: the correct answer, how many players picked each
+// option, their own outcome and the top of the leaderboard. Players who have
+// not answered yet are scored as timed out. In a survival game the room is
+// then told who is out.
+func (s *Service) RevealResults(code string, userID uint) error {
+	session, err := s.authorizeHost(code, userID)
+	if err != nil {
+		return err
+	}
+	if err := requireState(session, "reveal the results", models.SessionInProgress); err != nil {
+		return err
+	}
+	if session.IsSelfPaced() {
+		return ErrWrongPacing
+	}
+	if session.Phase == models.PhaseResults {
+		return ErrResultsShown
+	}
+
+	questions, err := s.sessionQuestions(session)
+	if err != nil {
+		return err
+	}
+	if session.CurrentIndex >= len(questions) {
+		return ErrNoActiveQuestion
+	}
+	question := questions[session.CurrentIndex]
+
+	// Close the question: whoever is still on the clock times out now.
+	for playerID, d := range s.clock.drain(session.JoinCode) {
+		s.handleQuestionTimeout(session.ID, playerID, d)
+	}
+
+	session.Phase = models.PhaseResults
+	if err := s.repo.UpdateSession(session); err != nil {
+		return err
+	}
+
+	responses, err := s.repo.GetQuestionResponses(session.ID, question.ID)
+	if err != nil {
+		return err
+	}
+	s.broadcastResults(session, question, session.CurrentIndex, len(questions), responses)
+	s.announceEliminations(session, question, session.CurrentIndex)
+	return nil
+}
+
+// broadcastResults sends the question_results message of a question.
+func (s *Service) broadcastResults(session *models.QuizSession, question models.Question, index, total int, responses []models.UserQuizResponse) {
+	options := optionResults(&question)
+	var summary AnswerSummary
+	results := make(map[uint]PlayerResult, len(responses))
+	for _, response := range responses {
+		credit := 0.0
+		if response.Answer != "" {
+			credit = evaluateAnswer(&question, response.Answer)
+		}
+		switch {
+		case response.Answer == "":
+			summary.Unanswered++
+		case credit >= 1:
+			summary.Correct++
+		case credit > 0:
+			summary.Partial++
+		default:
+			summary.Incorrect++
+		}
+		countPicks(&question, options, response.Answer)
+		results[response.UserID] = PlayerResult{
+			Answer:  response.Answer,
+			Credit:  credit,
+			Correct: credit >= 1,
+			Score:   response.Score,
+		}
+	}
+
+	entries, err := s.cache.GetLeaderboard(session.JoinCode)
+	if err != nil {
+		log.Printf("Error reading live leaderboard of session %s: %v", session.JoinCode, err)
+	}
+	teams := s.teamBoard(session, entries)
+	standings := s.leaderboards.standings(session.JoinCode, entries)
+	top := standings
+	if len(top) > leaderboardTopN {
+		top = top[:leaderboardTopN]
+	}
+	byUser := make(map[uint]LeaderboardStanding, len(standings))
+	for _, standing := range standings {
+		byUser[standing.UserID] = standing
+	}
+
+	log.Printf("Revealing results of question %d in session %s: %+v", question.ID, session.JoinCode, summary)
+	s.wsHub.BroadcastEach(session.JoinCode, "question_results", func(userID uint, role websocket.Role) interface{} {
+		message := QuestionResultsMessage{
+			SessionID:     session.ID,
+			QuestionID:    question.ID,
+			Index:         index,
+			Total:         total,
+			CorrectAnswer: question.CorrectAnswer,
+			Summary:       summary,
+			Top:           top,
+			Options:       options,
+			Teams:         teams,
+		}
+		if role == websocket.RolePlayer {
+			result := results[userID]
+			if standing, ok := byUser[userID]; ok {
+				result.Standing = &standing
+			}
+			message.You = &result
+		}
+		return message
+	})
+}
+
+// optionResults lists the options of a question whose answers pick options,
+// marking the correct ones. Other question types have no per-option counts.
+func optionResults(question *models.Question) []OptionResult {
+	var correct func(text string) bool
+	switch question.QuestionKind() {
+	case models.QuestionSingleChoice:
+		correct = func(text string) bool { return text == question.CorrectAnswer }
+	case models.QuestionTrueFalse:
+		correct = func(text string) bool { return sameBool(text, question.CorrectAnswer) }
+	case models.QuestionMultiSelect:
+		list, _ := parseAnswerList(question.CorrectAnswer)
+		correct = func(text string) bool { return contains(list, text) }
+	default:
+		return nil
+	}
+	if len(question.Options) == 0 {
+		return nil
+	}
+
+	options := make([]OptionResult, len(question.Options))
+	for i, option := range question.Options {
+		options[i] = OptionResult{ID: option.ID, Text: option.Text, Correct: correct(option.Text)}
+	}
+	return options
+}
+
+// countPicks adds an answer to the counts of the options it picked.
+func countPicks(question *models.Question, options []OptionResult, answer string) {
+	if options == nil || answer == "" {
+		return
+	}
+	for i := range options {
+		var picked bool
+		switch question.QuestionKind() {
+		case models.QuestionTrueFalse:
+			picked = sameBool(options[i].Text, answer)
+		case models.QuestionMultiSelect:
+			list, _ := parseAnswerList(answer)
+			picked = contains(list, options[i].Text)
+		default:
+			picked = options[i].Text == answer
+		}
+		if picked {
+			options[i].Count++
+		}
+	}
+}
+
+func sameBool(a, b string) bool {
+	x, errA := strconv.ParseBool(strings.TrimSpace(a))
+	y, errB := strconv.ParseBool(strings.TrimSpace(b))
+	return errA == nil && errB == nil && x == y
+}
+
+func contains(list []string, text string) bool {
+	for _, item := range list {
+		if item == text {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+// backend/internal/quiz/resume.go
+package quiz
+
+import (
+	"log"
+	"quiz-system/internal/models"
+	"time"
+)
+
+// ResumePlayer sends a player who (re)connected a "resume" message with the
+// state of their session, so a dropped connection does not cost them their
+// place: the question they are on with the time left on it, whether they have
+// answered it, the phase of the question and their score and standing.
+func (s *Service) ResumePlayer(code string, userID uint) error {
+	session, err := s.ResolveSession(code)
+	if err != nil {
+		return err
+	}
+	if userID == session.HostID {
+		return nil
+	}
+
+	state := &ResumeMessage{
+		SessionID: session.ID,
+		State:     session.State,
+		Pacing:    session.Pacing,
+		Phase:     session.Phase,
+		Deadline:  unixMilli(session.Deadline),
+	}
+
+	entries, err := s.cache.GetLeaderboard(session.JoinCode)
+	if err != nil {
+		log.Printf("Error reading live leaderboard of session %s: %v", session.JoinCode, err)
+	}
+	for _, standing := range rankEntries(entries, nil) {
+		if standing.UserID == userID {
+			state.You = &standing
+			break
+		}
+	}
+
+	eliminated, err := s.isEliminated(session, userID)
+	if err != nil {
+		return err
+	}
+	state.Eliminated = eliminated
+
+	pending := -1
+	if session.State == models.SessionInProgress || session.State == models.SessionPaused {
+		questions, err := s.sessionQuestions(session)
+		if err != nil {
+			return err
+		}
+		state.Total = len(questions)
+		if pending, err = s.resumeQuestion(session, userID, questions, state); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Resuming user %d in session %s", userID, session.JoinCode)
+	s.wsHub.SendMessageToUser(userID, "resume", state)
+	if pending >= 0 {
+		s.sendNextQuestion(session, userID, pending)
+	}
+	return nil
+}
+
+// resumeQuestion adds the player's current question to the resume state. It
+// returns the index of a self-paced question the player was never sent,
+// which the caller sends once the state is out, or -1.
+func (s *Service) resumeQuestion(session *models.QuizSession, userID uint, questions []models.Question, state *ResumeMessage) (int, error) {
+	total := len(questions)
+
+	// A running timer means the player has not answered the question yet.
+	d, left, ok, err := s.clock.remaining(session.JoinCode, userID)
+	if err != nil {
+		return -1, err
+	}
+	if ok && d.Index < total {
+		// This instance now wakes for the deadline too, in case the one that
+		// set it is gone.
+		s.clock.wake(session.JoinCode, userID, d)
+		question := questionMessage(session, questions[d.Index], d.Index, total, d.ExpiresAt(), false)
+		remaining, answered := left.Milliseconds(), false
+		state.Question, state.Remaining, state.Answered = &question, &remaining, &answered
+		return -1, nil
+	}
+
+	if session.IsSelfPaced() {
+		progress, err := s.repo.GetUserProgress(userID, session)
+		if err != nil {
+			return -1, err
+		}
+		if progress.NextIndex >= total {
+			state.Finished = true
+			return -1, nil
+		}
+		if session.State == models.SessionInProgress {
+			return progress.NextIndex, nil
+		}
+		return -1, nil
+	}
+
+	if session.CurrentIndex >= total {
+		return -1, nil
+	}
+	question := questions[session.CurrentIndex]
+	responses, err := s.repo.GetQuestionResponses(session.ID, question.ID)
+	if err != nil {
+		return -1, err
+	}
+	message := questionMessage(session, question, session.CurrentIndex, total, time.Now(), false)
+	remaining, answered := int64(0), false
+	state.Question, state.Remaining, state.Answered = &message, &remaining, &answered
+	for _, response := range responses {
+		if response.UserID != userID {
+			continue
+		}
+		answered = true
+		if session.Phase == models.PhaseResults {
+			credit := 0.0
+			if response.Answer != "" {
+				credit = evaluateAnswer(&question, response.Answer)
+			}
+			state.Result = &PlayerResult{
+				Answer:  response.Answer,
+				Credit:  credit,
+				Correct: credit >= 1,
+				Score:   response.Score,
+			}
+		}
+		break
+	}
+	return -1, nil
+}
@@ -0,0 +1,142 @@
+// backend/internal/quiz/scoring.go
+package quiz
+
+import (
+	"fmt"
+	"math"
+	"quiz-system/internal/models"
+	"time"
+)
+
+// ScoreInput is what a scoring strategy knows about one graded answer.
+type ScoreInput struct {
+	Credit  float64       // share of the answer that was correct, from 0 to 1
+	Elapsed time.Duration // time the player took, measured by the server
+	Limit   time.Duration // the question's time limit
+	Weight  float64       // the question's point weight
+	Streak  int           // fully correct answers in a row before this one
+}
+
+// ScoringStrategy turns a graded answer into points.
+type ScoringStrategy interface {
+	Score(in ScoreInput) float64
+}
+
+// newScoringStrategy builds the strategy a scoring configuration describes:
+// the base strategy, optionally with a streak multiplier and negative marking,
+// all scaled by the question's weight.
+func newScoringStrategy(config models.ScoringConfig) ScoringStrategy {
+	config = config.WithDefaults()
+	base := float64(config.BasePoints)
+
+	var strategy ScoringStrategy
+	switch config.Strategy {
+	case models.ScoringSpeed:
+		strategy = speedScoring{base: base}
+	case models.ScoringFlat:
+		strategy = flatScoring{base: base}
+	default:
+		strategy = classicScoring{base: base}
+	}
+	if config.StreakBonus > 0 {
+		strategy = streakScoring{next: strategy, bonus: config.StreakBonus, max: config.MaxStreakMultiplier}
+	}
+	if config.WrongPenalty > 0 {
+		strategy = negativeMarking{next: strategy, penalty: float64(config.WrongPenalty)}
+	}
+	return weightedScoring{next: strategy}
+}
+
+// validateScoring checks a scoring configuration before it is saved.
+func validateScoring(config models.ScoringConfig) error {
+	switch config.WithDefaults().Strategy {
+	case models.ScoringClassic, models.ScoringSpeed, models.ScoringFlat:
+	default:
+		return fmt.Errorf("%w: unknown scoring strategy %q", ErrInvalidInput, config.Strategy)
+	}
+	if config.BasePoints < 0 || config.WrongPenalty < 0 || config.StreakBonus < 0 {
+		return fmt.Errorf("%w: scoring values cannot be negative", ErrInvalidInput)
+	}
+	if config.MaxStreakMultiplier != 0 && config.MaxStreakMultiplier < 1 {
+		return fmt.Errorf("%w: max streak multiplier must be at least 1", ErrInvalidInput)
+	}
+	return nil
+}
+
+// scoreAnswer rounds the points a strategy gives an answer.
+func scoreAnswer(strategy ScoringStrategy, in ScoreInput) int {
+	return int(math.Round(strategy.Score(in)))
+}
+
+// classicScoring deducts one percent of the base points per whole second.
+type classicScoring struct {
+	base float64
+}
+
+func (c classicScoring) Score(in ScoreInput) float64 {
+	seconds := math.Floor(in.Elapsed.Seconds())
+	return in.Credit * math.Max(0, c.base-c.base*seconds/100)
+}
+
+// speedScoring decays linearly from the base points for an instant answer to
+// half of them at the time limit.
+type speedScoring struct {
+	base float64
+}
+
+func (s speedScoring) Score(in ScoreInput) float64 {
+	if in.Limit <= 0 {
+		return in.Credit * s.base
+	}
+	ratio := math.Min(1, in.Elapsed.Seconds()/in.Limit.Seconds())
+	return in.Credit * s.base * (1 - ratio/2)
+}
+
+type flatScoring struct {
+	base float64
+}
+
+func (f flatScoring) Score(in ScoreInput) float64 {
+	return in.Credit * f.base
+}
+
+// streakScoring rewards fully correct answers given in a row.
+type streakScoring struct {
+	next  ScoringStrategy
+	bonus float64
+	max   float64
+}
+
+func (s streakScoring) Score(in ScoreInput) float64 {
+	points := s.next.Score(in)
+	if in.Credit < 1 || in.Streak == 0 {
+		return points
+	}
+	multiplier := 1 + s.bonus*float64(in.Streak)
+	if s.max > 0 {
+		multiplier = math.Min(multiplier, s.max)
+	}
+	return points * multiplier
+}
+
+// negativeMarking deducts a penalty for answers that earned no credit.
+type negativeMarking struct {
+	next    ScoringStrategy
+	penalty float64
+}
+
+func (n negativeMarking) Score(in ScoreInput) float64 {
+	if in.Credit <= 0 {
+		return -n.penalty
+	}
+	return n.next.Score(in)
+}
+
+// weightedScoring scales the points, or the penalty, by the question's weight.
+type weightedScoring struct {
+	next ScoringStrategy
+}
+
+func (w weightedScoring) Score(in ScoreInput) float64 {
+	return w.next.Score(in) * in.Weight
+}
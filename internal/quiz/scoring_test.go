@@ -0,0 +1,97 @@
+// backend/internal/quiz/scoring_test.go
+package quiz
+
+import (
+	"errors"
+	"quiz-system/internal/models"
+	"testing"
+	"time"
+)
+
+func TestScoreAnswer(t *testing.T) {
+	classic := models.ScoringConfig{Strategy: models.ScoringClassic, BasePoints: 1000}
+	speed := models.ScoringConfig{Strategy: models.ScoringSpeed, BasePoints: 1000}
+	flat := models.ScoringConfig{Strategy: models.ScoringFlat, BasePoints: 1000}
+	streak := models.ScoringConfig{Strategy: models.ScoringFlat, BasePoints: 1000, StreakBonus: 0.1}
+	cappedStreak := models.ScoringConfig{Strategy: models.ScoringFlat, BasePoints: 1000, StreakBonus: 0.1, MaxStreakMultiplier: 1.2}
+	negative := models.ScoringConfig{Strategy: models.ScoringFlat, BasePoints: 1000, WrongPenalty: 250}
+	everything := models.ScoringConfig{Strategy: models.ScoringFlat, BasePoints: 1000, StreakBonus: 0.5, WrongPenalty: 100}
+
+	tests := []struct {
+		name   string
+		config models.ScoringConfig
+		in     ScoreInput
+		want   int
+	}{
+		{"classic instant", classic, ScoreInput{Credit: 1, Weight: 1}, 1000},
+		{"classic counts whole seconds", classic, ScoreInput{Credit: 1, Elapsed: 2900 * time.Millisecond, Weight: 1}, 980},
+		{"classic never below zero", classic, ScoreInput{Credit: 1, Elapsed: 150 * time.Second, Weight: 1}, 0},
+		{"classic partial credit", classic, ScoreInput{Credit: 1.0 / 3, Weight: 1}, 333},
+		{"defaults to classic", models.ScoringConfig{BasePoints: 1000}, ScoreInput{Credit: 1, Elapsed: 10 * time.Second, Weight: 1}, 900},
+
+		{"speed instant", speed, ScoreInput{Credit: 1, Limit: 20 * time.Second, Weight: 1}, 1000},
+		{"speed halfway", speed, ScoreInput{Credit: 1, Elapsed: 10 * time.Second, Limit: 20 * time.Second, Weight: 1}, 750},
+		{"speed at the limit", speed, ScoreInput{Credit: 1, Elapsed: 20 * time.Second, Limit: 20 * time.Second, Weight: 1}, 500},
+		{"speed past the limit", speed, ScoreInput{Credit: 1, Elapsed: 40 * time.Second, Limit: 20 * time.Second, Weight: 1}, 500},
+		{"speed without a limit", speed, ScoreInput{Credit: 1, Elapsed: 40 * time.Second, Weight: 1}, 1000},
+
+		{"flat", flat, ScoreInput{Credit: 1, Elapsed: time.Minute, Weight: 1}, 1000},
+		{"flat partial credit", flat, ScoreInput{Credit: 0.5, Weight: 1}, 500},
+		{"flat wrong", flat, ScoreInput{Credit: 0, Weight: 1}, 0},
+
+		{"streak of three", streak, ScoreInput{Credit: 1, Weight: 1, Streak: 3}, 1300},
+		{"no streak yet", streak, ScoreInput{Credit: 1, Weight: 1}, 1000},
+		{"streak ignores partial answers", streak, ScoreInput{Credit: 0.5, Weight: 1, Streak: 3}, 500},
+		{"streak capped", cappedStreak, ScoreInput{Credit: 1, Weight: 1, Streak: 5}, 1200},
+		{"streak under the cap", cappedStreak, ScoreInput{Credit: 1, Weight: 1, Streak: 1}, 1100},
+
+		{"negative marking wrong", negative, ScoreInput{Credit: 0, Weight: 1}, -250},
+		{"negative marking partial", negative, ScoreInput{Credit: 0.5, Weight: 1}, 500},
+		{"negative marking correct", negative, ScoreInput{Credit: 1, Weight: 1}, 1000},
+
+		{"weight doubles points", flat, ScoreInput{Credit: 1, Weight: 2}, 2000},
+		{"weight halves points", flat, ScoreInput{Credit: 1, Weight: 0.5}, 500},
+		{"weight scales the penalty", negative, ScoreInput{Credit: 0, Weight: 2}, -500},
+		{"weight scales the streak", streak, ScoreInput{Credit: 1, Weight: 2, Streak: 2}, 2400},
+
+		{"all modifiers correct", everything, ScoreInput{Credit: 1, Weight: 3, Streak: 1}, 4500},
+		{"all modifiers wrong", everything, ScoreInput{Credit: 0, Weight: 3, Streak: 4}, -300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scoreAnswer(newScoringStrategy(tt.config), tt.in)
+			if got != tt.want {
+				t.Errorf("scoreAnswer(%+v) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateScoring(t *testing.T) {
+	tests := []struct {
+		name   string
+		config models.ScoringConfig
+		valid  bool
+	}{
+		{"defaults", models.ScoringConfig{}, true},
+		{"speed with modifiers", models.ScoringConfig{Strategy: models.ScoringSpeed, StreakBonus: 0.2, MaxStreakMultiplier: 2, WrongPenalty: 50}, true},
+		{"unknown strategy", models.ScoringConfig{Strategy: "random"}, false},
+		{"negative base points", models.ScoringConfig{BasePoints: -1}, false},
+		{"negative penalty", models.ScoringConfig{WrongPenalty: -10}, false},
+		{"negative streak bonus", models.ScoringConfig{StreakBonus: -0.1}, false},
+		{"streak cap below one", models.ScoringConfig{StreakBonus: 0.1, MaxStreakMultiplier: 0.5}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateScoring(tt.config)
+			if tt.valid && err != nil {
+				t.Errorf("validateScoring() = %v, want nil", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("validateScoring() = %v, want ErrInvalidInput", err)
+			}
+		})
+	}
+}
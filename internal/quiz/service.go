@@ -3,44 +3,47 @@ package quiz
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"math/rand"
 	"quiz-system/internal/models"
 	"quiz-system/pkg/cache"
 	"quiz-system/pkg/websocket"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 type Service struct {
-	repo  *Repository
-	cache *cache.RedisCache
-	wsHub *websocket.Hub
+	repo         *Repository
+	cache        *cache.RedisCache
+	wsHub        *websocket.Hub
+	clock        *questionClock
+	leaderboards *leaderboardFeed
+	sessionMu    sync.Mutex // serialises opening sessions on demand
+	// issueScreenToken mints the tokens of spectator displays.
+	issueScreenToken ScreenTokenIssuer
 }
 
 func NewService(repo *Repository, cache *cache.RedisCache, wsHub *websocket.Hub) *Service {
-	return &Service{
-		repo:  repo,
-		cache: cache,
-		wsHub: wsHub,
+	s := &Service{
+		repo:         repo,
+		cache:        cache,
+		wsHub:        wsHub,
+		leaderboards: newLeaderboardFeed(cache),
 	}
+	s.clock = newQuestionClock(cache, s.questionTimedOut)
+	return s
 }
 
-// backend/internal/quiz/service.go
-type LeaderboardEntry struct {
-    Username string `json:"username"`
-    Score    int    `json:"score"`
-}
-
-
-var userQuizProgress = map[uint]map[string]int{} 
-
-
-func (s *Service) GetLeaderboard(quizCode string) ([]models.LeaderboardEntry, error) {
-    quiz, err := s.GetQuizByCode(quizCode)
+func (s *Service) GetLeaderboard(code string) ([]models.LeaderboardEntry, error) {
+    session, err := s.GetSession(code)
     if err != nil {
         return nil, err
     }
 
-    entries, err := s.repo.GetLeaderboard(quiz.ID)
+    entries, err := s.repo.GetLeaderboard(session.ID)
     if err != nil {
         return nil, err
     }
@@ -48,95 +51,113 @@ func (s *Service) GetLeaderboard(quizCode string) ([]models.LeaderboardEntry, er
     return entries, nil
 }
 
-func (s *Service) StartQuiz(quizCode string, userID uint) error {
-	log.Printf("StartQuiz called for quiz %s by user %d", quizCode, userID)
+// StartQuiz starts the lobby of a code and returns its session. A quiz code
+// without a current session only gets its lobby opened, so nobody misses the
+// first question; the session comes back still in the lobby state.
+func (s *Service) StartQuiz(code string, userID uint) (*models.QuizSession, error) {
+	log.Printf("StartQuiz called for %s by user %d", code, userID)
 
-	quiz, err := s.GetQuizByCode(quizCode)
+	session, opened, err := s.sessionToStart(code, userID)
 	if err != nil {
-		log.Printf("Error getting quiz: %v", err)
-		return err
+		log.Printf("Error authorizing quiz start: %v", err)
+		return nil, err
+	}
+	if opened {
+		return session, nil
+	}
+	if err := requireState(session, "start the quiz", models.SessionLobby); err != nil {
+		return nil, err
 	}
 
-            // Reset progress for all participants for this quiz.
-            if err := s.repo.ResetQuizProgress(quiz.ID); err != nil {
-                return err
-            }
-
-	questions, err := s.repo.GetQuizQuestions(quiz.ID)
+	questions, err := s.sessionQuestions(session)
 	if err != nil {
 		log.Printf("Error getting questions: %v", err)
-		return err
+		return nil, err
 	}
 
 	if len(questions) == 0 {
-		log.Printf("No questions found for quiz %s", quizCode)
-		return errors.New("no questions found for quiz")
+		log.Printf("No questions found for session %s", session.JoinCode)
+		return nil, ErrNoQuestions
 	}
 
-
-
-	// Set quiz as active
-	quiz.IsActive = true
-	if err := s.repo.UpdateQuiz(quiz); err != nil {
-		log.Printf("Error updating quiz status: %v", err)
-		return err
+	// Set session as in progress
+	if err := s.transition(session, "start the quiz", models.SessionInProgress); err != nil {
+		log.Printf("Error updating session status: %v", err)
+		return nil, err
 	}
+	s.setQuizActive(session.QuizID, true)
 
-    firstQuestionDTO := questions[0].ToDTO(true)
-    messageData := map[string]interface{}{
-		"question": firstQuestionDTO,
-		"index":    0,
-		"total":    len(questions),
-		"quizId":   quiz.ID,
+	if session.IsSelfPaced() {
+		err = s.startSelfPaced(session, questions)
+	} else {
+		err = s.startHostPaced(session, questions)
 	}
-
-	log.Printf("Broadcasting first question data: %+v", messageData)
-	s.wsHub.BroadcastMessage(quizCode, "question", messageData)
-
-	return nil
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
 }
 
 
-func (s *Service) RemoveParticipant(quizCode string, userID uint) error {
-    quiz, err := s.GetQuizByCode(quizCode)
+func (s *Service) RemoveParticipant(code string, userID uint) error {
+    session, err := s.ResolveSession(code)
     if err != nil {
-        log.Printf("Error getting quiz by code %s: %v", quizCode, err)
+        log.Printf("Error getting session by code %s: %v", code, err)
         return err
     }
 
     // Check if the user is the host
-    if quiz.CreatorID == userID {
-        log.Printf("User %d is the host of quiz %s, ignoring removal", userID, quizCode)
+    if session.HostID == userID {
+        log.Printf("User %d is the host of session %s, ignoring removal", userID, session.JoinCode)
+        return nil
+    }
+    // Players who leave a finished session keep their results.
+    if !session.State.IsOpen() {
+        log.Printf("Session %s is %s, keeping participant %d", session.JoinCode, session.State, userID)
         return nil
     }
 
+    return s.removeParticipant(session, userID)
+}
+
+// removeParticipant deletes a player from the session along with their
+// progress, answers and live leaderboard entry.
+func (s *Service) removeParticipant(session *models.QuizSession, userID uint) error {
+    // Stop their question timer so a timeout is not recorded after they left.
+    if d, ok, _ := s.clock.peek(session.JoinCode, userID); ok {
+        s.clock.stop(session.JoinCode, userID, d.QuestionID)
+    }
+
     // Remove from database
-    err = s.repo.RemoveParticipant(quiz.ID, userID)
+    err := s.repo.RemoveParticipant(session.ID, userID)
     if err != nil {
-        log.Printf("Error removing participant %d from quiz %s in database: %v", userID, quizCode, err)
+        log.Printf("Error removing participant %d from session %s in database: %v", userID, session.JoinCode, err)
         return err
     }
 
     // Clear user's progress
-    err = s.repo.ClearUserProgress(quiz.ID, userID)
+    err = s.repo.ClearUserProgress(session.ID, userID)
     if err != nil {
-        log.Printf("Error clearing progress for user %d in quiz %s: %v", userID, quizCode, err)
+        log.Printf("Error clearing progress for user %d in session %s: %v", userID, session.JoinCode, err)
         // Continue execution even if clearing progress fails
     }
 
     // Remove any cached data for this user
-    err = s.cache.RemoveUserQuizData(quizCode, userID)
+    err = s.cache.RemoveUserQuizData(session.JoinCode, userID)
     if err != nil {
-        log.Printf("Error clearing cached data for user %d in quiz %s: %v", userID, quizCode, err)
+        log.Printf("Error clearing cached data for user %d in session %s: %v", userID, session.JoinCode, err)
         // Continue execution even if cache clearing fails
     }
+    if err := s.cache.RemoveFromLeaderboard(session.JoinCode, userID); err != nil {
+        log.Printf("Error removing user %d from leaderboard of session %s: %v", userID, session.JoinCode, err)
+    }
 
     // Update participant count and notify all clients
     if s.wsHub != nil {
-        s.wsHub.SendParticipantList(quizCode)
+        s.wsHub.SendParticipantList(session.JoinCode)
     }
 
-    log.Printf("Successfully removed participant %d from quiz %s", userID, quizCode)
+    log.Printf("Successfully removed participant %d from session %s", userID, session.JoinCode)
     return nil
 }
 
@@ -144,45 +165,66 @@ func (s *Service) GetQuizzesByCreator(userID uint) ([]models.Quiz, error) {
 	return s.repo.GetQuizzesByCreator(userID)
 }
 
-// In service.go
-func (s *Service) HandleNextQuestion(quizCode string, currentIndex int) error {
-    log.Printf("Handling next question for quiz %s, current index: %d", quizCode, currentIndex)
+func (s *Service) HandleNextQuestion(code string, userID uint, currentIndex int) error {
+    log.Printf("Handling next question for %s, current index: %d", code, currentIndex)
     
-    quiz, err := s.GetQuizByCode(quizCode)
+    session, err := s.authorizeHost(code, userID)
     if err != nil {
-        log.Printf("Error getting quiz: %v", err)
+        log.Printf("Error authorizing next question: %v", err)
         return err
     }
+    if err := requireState(session, "advance the quiz", models.SessionInProgress); err != nil {
+        return err
+    }
+    if session.IsSelfPaced() {
+        return ErrWrongPacing
+    }
+    // The session tracks the current question; a stale index, such as a
+    // double-clicked "next", must not skip a question.
+    if currentIndex != session.CurrentIndex {
+        return fmt.Errorf("%w: the session is on question %d, not %d", ErrInvalidInput, session.CurrentIndex, currentIndex)
+    }
 
-    questions, err := s.repo.GetQuizQuestions(quiz.ID)
+    questions, err := s.sessionQuestions(session)
     if err != nil {
         log.Printf("Error getting questions: %v", err)
         return err
     }
 
+    if currentIndex < len(questions) {
+        s.closeSurvivalQuestion(session, questions[currentIndex])
+    }
+
     nextIndex := currentIndex + 1
     log.Printf("Next index will be: %d, total questions: %d", nextIndex, len(questions))
 
-    if nextIndex >= len(questions) {
-        log.Printf("Quiz %s finished, broadcasting quiz_end", quizCode)
-        s.wsHub.BroadcastMessage(quizCode, "quiz_end", nil)
-        return nil
-    }
+    return s.advanceTo(session, questions, nextIndex)
+}
 
-    nextQuestion := questions[nextIndex]
-    nextQuestionDTO := nextQuestion.ToDTO(true)
+// advanceTo sends a host-paced session's players the question at nextIndex,
+// or ends the quiz when there is none or a survival game is over.
+func (s *Service) advanceTo(session *models.QuizSession, questions []models.Question, nextIndex int) error {
+	if s.survivalOver(session, nextIndex, len(questions)) || nextIndex >= len(questions) {
+		log.Printf("Session %s finished, broadcasting quiz_end", session.JoinCode)
+		if err := s.finishSession(session); err != nil {
+			return err
+		}
+		s.wsHub.BroadcastMessage(session.JoinCode, "quiz_end", nil)
+		return nil
+	}
 
-    messageData := map[string]interface{}{
-        "question": nextQuestionDTO,
-        "index":    nextIndex,
-        "total":    len(questions),
-        "quizId":   quiz.ID,
-    }
+	session.CurrentIndex = nextIndex
+	session.Phase = models.PhaseQuestion
+	if err := s.repo.UpdateSession(session); err != nil {
+		return err
+	}
 
-    log.Printf("Broadcasting next question for quiz %s: %+v", quizCode, messageData)
-    s.wsHub.BroadcastMessage(quizCode, "question", messageData)
-    
-    return nil
+	nextQuestion := questions[nextIndex]
+	expiresAt := s.armRoom(session, nextQuestion, nextIndex, time.Now())
+
+	log.Printf("Broadcasting question %d of session %s", nextIndex, session.JoinCode)
+	s.broadcastQuestion(session, nextQuestion, nextIndex, len(questions), expiresAt)
+	return nil
 }
 
 
@@ -190,6 +232,23 @@ func (s *Service) CreateQuiz(quiz *models.Quiz) error {
 	// Generate unique quiz code
 	quiz.QuizCode = generateQuizCode()
 	quiz.IsActive = false
+	if err := validateScoring(quiz.Scoring); err != nil {
+		return err
+	}
+	if err := validatePacing(quiz.Pacing); err != nil {
+		return err
+	}
+
+	// Questions and options keep the order they were submitted in.
+	for i := range quiz.Questions {
+		if err := validateQuestion(&quiz.Questions[i]); err != nil {
+			return fmt.Errorf("question %d: %w", i+1, err)
+		}
+		quiz.Questions[i].Position = i
+		for j := range quiz.Questions[i].Options {
+			quiz.Questions[i].Options[j].Position = j
+		}
+	}
 
 	if err := s.repo.CreateQuiz(quiz); err != nil {
 		return err
@@ -218,137 +277,405 @@ func (s *Service) GetQuizByCode(code string) (*models.Quiz, error) {
 	return quiz, nil
 }
 
-func (s *Service) JoinQuiz(quizCode string, userID uint) error {
-    quiz, err := s.GetQuizByCode(quizCode)
+// GetQuizForUser returns the quiz as userID may see it: only the host gets the
+// correct answers of its questions and the options of ordering questions in
+// their stored, correct sequence. Players get those shuffled with the seed of
+// the quiz's latest session, so the order matches the one they play.
+func (s *Service) GetQuizForUser(code string, userID uint) (*models.Quiz, error) {
+	quiz, err := s.GetQuizByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if quiz.CreatorID == userID {
+		return quiz, nil
+	}
+
+	seed := rand.Int63()
+	if session, err := s.repo.GetLatestSession(quiz.ID); err == nil {
+		seed = session.ShuffleSeed
+	}
+	sanitized := *quiz
+	sanitized.Questions = make([]models.Question, len(quiz.Questions))
+	for i, question := range quiz.Questions {
+		question.CorrectAnswer = ""
+		if question.QuestionKind() == models.QuestionOrdering {
+			shuffleOptions(&question, seed)
+		}
+		sanitized.Questions[i] = question
+	}
+	return &sanitized, nil
+}
+
+func (s *Service) JoinQuiz(code string, userID uint) (*models.QuizSession, error) {
+    session, err := s.ResolveSession(code)
     if err != nil {
-        return err
+        return nil, err
     }
 
-    if userID == quiz.CreatorID {
-        log.Printf("User %d is the host for quiz %s", userID, quizCode)
-        return nil
+    if userID == session.HostID {
+        log.Printf("User %d is the host for session %s", userID, session.JoinCode)
+        return session, nil
+    }
+    if !session.State.IsOpen() {
+        return nil, &StateError{Op: "join the quiz", State: session.State}
+    }
+    banned, err := s.repo.IsBanned(session.ID, userID)
+    if err != nil {
+        return nil, err
+    }
+    if banned {
+        log.Printf("User %d is banned from session %s", userID, session.JoinCode)
+        return nil, ErrBanned
     }
 
-    err = s.repo.AddParticipant(quiz.ID, userID)
+    joined := false
+    for _, participant := range session.Participants {
+        if participant.UserID == userID {
+            joined = true
+            break
+        }
+    }
+
+    err = s.repo.AddParticipant(session, userID)
     if err != nil {
-        return err
+        return nil, err
     }
+    s.placeInTeam(session, userID)
+    s.eliminateLateJoiner(session, userID, joined)
 
     // Notify WebSocket hub of the new participant
     if s.wsHub != nil { // Assuming you have a reference to the WebSocket hub
-        s.wsHub.SendParticipantList(quizCode)
+        s.wsHub.SendParticipantList(session.JoinCode)
     }
 
-    return nil
+    return session, nil
 }
 
 
 func (s *Service) ProcessAnswer(response *models.UserQuizResponse) (int, error) {
-    // Retrieve the quiz details first.
-    quiz, err := s.repo.GetQuizByID(response.QuizID)
+    // Retrieve the session details first.
+    session, err := s.sessionForResponse(response)
     if err != nil {
         return 0, err
     }
+    response.SessionID = session.ID
+    response.QuizID = session.QuizID
+
+    // A retried request gets the result of the original one.
+    if response.IdempotencyKey != "" {
+        original, err := s.repo.GetResponseByIdempotencyKey(session.ID, response.UserID, response.IdempotencyKey)
+        if err == nil {
+            log.Printf("Replaying answer %s of user %d", response.IdempotencyKey, response.UserID)
+            return original.Score, nil
+        }
+        if !errors.Is(err, gorm.ErrRecordNotFound) {
+            return 0, err
+        }
+    }
+
     // If the answer comes from the host, ignore it.
-    if response.UserID == quiz.CreatorID {
+    if response.UserID == session.HostID {
         log.Printf("User %d is host; skipping answer processing.", response.UserID)
         return 0, nil
     }
+    if err := requireState(session, "answer", models.SessionInProgress); err != nil {
+        return 0, err
+    }
+    if err := s.requireContender(session, response.UserID); err != nil {
+        return 0, err
+    }
 
     // Retrieve the question details
     question, err := s.repo.GetQuestion(response.QuestionID)
     if err != nil {
         return 0, err
     }
+    if question.QuizID != session.QuizID {
+        return 0, fmt.Errorf("%w: question %d is not part of this quiz", ErrInvalidInput, question.ID)
+    }
+    answered, err := s.repo.HasResponse(session.ID, response.UserID, question.ID)
+    if err != nil {
+        return 0, err
+    }
+    if answered {
+        return s.replayAnswer(response, ErrAlreadyAnswered)
+    }
+
+    // The answer must belong to the question the server last sent this
+    // player, and arrive before that question's time limit runs out.
+    deadline, ok, err := s.clock.peek(session.JoinCode, response.UserID)
+    if err != nil {
+        return 0, err
+    }
+    if !ok || deadline.QuestionID != question.ID {
+        return s.replayAnswer(response, ErrNoActiveQuestion)
+    }
+    now := time.Now()
+    if now.After(deadline.ExpiresAt().Add(answerGracePeriod)) {
+        return 0, ErrAnswerTooLate
+    }
+    // Time spent is measured by the server; the client-reported value is ignored.
+    response.TimeSpent = int(deadline.Elapsed(now).Seconds())
+
+    // Grade the answer for the question's type, then score it with the
+    // session's scoring strategy. The streak is read and updated while the
+    // player's progress is locked.
+    credit := evaluateAnswer(question, response.Answer)
+    survived := survives(session.Survival, credit, deadline.Elapsed(now))
+    strategy := newScoringStrategy(session.Scoring)
+    err = s.recordResponse(session, response, deadline.Index, func(progress *models.UserQuizProgress) {
+        response.Score = scoreAnswer(strategy, ScoreInput{
+            Credit:  credit,
+            Elapsed: deadline.Elapsed(now),
+            Limit:   deadline.Limit,
+            Weight:  question.EffectiveWeight(),
+            Streak:  progress.Streak,
+        })
+        log.Printf("User %d earned %d points (credit %.2f, streak %d)", response.UserID, response.Score, credit, progress.Streak)
+        if credit >= 1 {
+            progress.Streak++
+        } else {
+            progress.Streak = 0
+        }
+        judgeSurvival(session, progress, deadline.Index, survived)
+    })
+    if err != nil {
+        return s.replayAnswer(response, err)
+    }
 
+    // The answer is in, so the player's timer is no longer needed.
+    s.clock.stop(session.JoinCode, response.UserID, question.ID)
+    s.addScore(session, response.UserID, response.Score)
+    s.afterResponse(session, response.UserID, question.ID, deadline.Index)
 
-    // Calculate score based on answer, correct answer, and time spent
-    score := calculateScore(response.Answer, question.CorrectAnswer, response.TimeSpent)
-    response.Score = score
+    return response.Score, nil
+}
 
-    // Save the user's response to the database
-    if err := s.repo.SaveResponse(response); err != nil {
+// replayAnswer handles an answer refused with err because the player already
+// answered. A concurrent retry of the same request may have recorded it after
+// the idempotency key was first checked, in which case the retry gets the
+// original score; otherwise err is returned.
+func (s *Service) replayAnswer(response *models.UserQuizResponse, err error) (int, error) {
+    if response.IdempotencyKey == "" || !(errors.Is(err, ErrAlreadyAnswered) || errors.Is(err, ErrNoActiveQuestion)) {
+        return 0, err
+    }
+    original, lookupErr := s.repo.GetResponseByIdempotencyKey(response.SessionID, response.UserID, response.IdempotencyKey)
+    if lookupErr != nil {
         return 0, err
     }
+    log.Printf("Replaying answer %s of user %d recorded by a concurrent request", response.IdempotencyKey, response.UserID)
+    return original.Score, nil
+}
 
-    // Get the user's current progress (next question index)
-    currentIndex, err := s.repo.GetUserQuestionIndex(response.UserID, quiz.ID)
-    if err != nil {
-        currentIndex = 0
+// sessionForResponse finds the session an answer belongs to. Clients that only
+// send the quiz ID answer in the quiz's open session.
+func (s *Service) sessionForResponse(response *models.UserQuizResponse) (*models.QuizSession, error) {
+    if response.SessionID != 0 {
+        return s.repo.GetSessionByID(response.SessionID)
     }
-    log.Printf("User %d is at question index %d", response.UserID, currentIndex)
+    return s.repo.GetOpenSession(response.QuizID)
+}
 
-    // Increment progress
-    newIndex := currentIndex + 1
-    if err := s.repo.UpdateUserQuestionIndex(response.UserID, quiz.ID, newIndex); err != nil {
-        log.Printf("Error updating question index for user %d: %v", response.UserID, err)
+// recordResponse saves a response to the question at index and advances the
+// player past it in one transaction. score runs while the player's progress is
+// locked and sets the response's score and the player's streak. It fails with
+// ErrAlreadyAnswered if the player has already moved past the question, for
+// instance because a concurrent submission or the timeout got there first.
+func (s *Service) recordResponse(session *models.QuizSession, response *models.UserQuizResponse, index int, score func(progress *models.UserQuizProgress)) error {
+    err := s.repo.RecordResponse(session, response, func(progress *models.UserQuizProgress) error {
+        log.Printf("User %d is at question index %d", response.UserID, progress.NextIndex)
+        if progress.NextIndex > index {
+            return ErrAlreadyAnswered
+        }
+        // Self-paced players go through every question in turn. In host-paced
+        // play a player who joined late simply has no response for the
+        // questions before they arrived.
+        if progress.NextIndex < index && session.IsSelfPaced() {
+            return ErrNoActiveQuestion
+        }
+        score(progress)
+        progress.NextIndex = index + 1
+        return nil
+    })
+    if errors.Is(err, gorm.ErrDuplicatedKey) {
+        return ErrAlreadyAnswered
     }
+    return err
+}
 
-    // Trigger sending next question only to this participant
-    go func(userID uint, quizCode string, nextIndex int) {
+// sendNextQuestion sends the player the question at nextIndex, or the end of
+// the quiz, once the room is done with what it is handling. Queuing it on the
+// room keeps two players who finish together from both ending the session.
+func (s *Service) sendNextQuestion(session *models.QuizSession, userID uint, nextIndex int) {
+    quizCode := session.JoinCode
+    s.inRoom(quizCode, func() {
         if err := s.HandleNextQuestionForUser(userID, quizCode, nextIndex); err != nil {
             log.Printf("Error sending next question to user %d: %v", userID, err)
         }
-    }(response.UserID, quiz.QuizCode, newIndex)
+    })
+}
 
-    return score, nil
+// armQuestion starts the server-side timer for a question sent to one player.
+func (s *Service) armQuestion(session *models.QuizSession, userID uint, question models.Question, index int, sentAt time.Time) questionDeadline {
+	return s.clock.start(session.JoinCode, userID, session.ID, question, index, sentAt)
+}
+
+// questionTimedOut handles a player's question timer running out on the
+// session's room.
+func (s *Service) questionTimedOut(quizCode string, userID uint, d questionDeadline) {
+	s.inRoom(quizCode, func() {
+		s.handleQuestionTimeout(d.SessionID, userID, d)
+	})
+}
+
+// inRoom runs fn on the goroutine that owns the session's room, in order with
+// the room's joins, leaves and player messages.
+func (s *Service) inRoom(quizCode string, fn func()) {
+	if s.wsHub == nil {
+		fn()
+		return
+	}
+	s.wsHub.Dispatch(quizCode, fn)
 }
 
-func (s *Service) HandleNextQuestionForUser(userID uint, quizCode string, nextIndex int) error {
-    log.Printf("Handling next question for user %d in quiz %s, next index: %d", userID, quizCode, nextIndex)
+// inRoomWait runs fn like inRoom and returns its error once it has run. It
+// must not be called from the room's own goroutine.
+func (s *Service) inRoomWait(quizCode string, fn func() error) error {
+	done := make(chan error, 1)
+	s.inRoom(quizCode, func() {
+		done <- fn()
+	})
+	return <-done
+}
+
+// RunInRoom runs fn on the goroutine of the room of the session code stands
+// for and returns fn's error. REST handlers use it so their requests are
+// handled in order with the room's WebSocket messages, as the same request
+// sent over the socket would be. A code without a current session has no
+// room yet, so fn runs right away and reports the error itself.
+func (s *Service) RunInRoom(code string, fn func() error) error {
+	session, err := s.ResolveSession(code)
+	if err != nil {
+		return fn()
+	}
+	return s.inRoomWait(session.JoinCode, fn)
+}
+
+// SubmitAnswer processes an answer sent over REST on the goroutine of its
+// session's room, like an answer sent over the WebSocket.
+func (s *Service) SubmitAnswer(response *models.UserQuizResponse) (int, error) {
+	session, err := s.sessionForResponse(response)
+	if err != nil {
+		return s.ProcessAnswer(response)
+	}
+	var score int
+	err = s.inRoomWait(session.JoinCode, func() error {
+		var err error
+		score, err = s.ProcessAnswer(response)
+		return err
+	})
+	return score, err
+}
+
+// armRoom starts the timer of a question broadcast to every participant who
+// may answer it and returns when it expires.
+func (s *Service) armRoom(session *models.QuizSession, question models.Question, index int, sentAt time.Time) time.Time {
+	userIDs, err := s.repo.GetParticipantIDs(session.ID)
+	if err != nil {
+		log.Printf("Error getting participants of session %s: %v", session.JoinCode, err)
+	}
+	for _, userID := range s.contenders(session, index, userIDs) {
+		s.armQuestion(session, userID, question, index, sentAt)
+	}
+	return sentAt.Add(time.Duration(question.EffectiveTimeLimit()) * time.Second)
+}
+
+// handleQuestionTimeout scores an unanswered question as zero and moves the
+// player on.
+func (s *Service) handleQuestionTimeout(sessionID uint, userID uint, d questionDeadline) {
+	session, err := s.repo.GetSessionByID(sessionID)
+	if err != nil {
+		log.Printf("Error loading session %d after timeout: %v", sessionID, err)
+		return
+	}
+	log.Printf("User %d timed out on question %d of session %s", userID, d.QuestionID, session.JoinCode)
+
+	response := &models.UserQuizResponse{
+		UserID:     userID,
+		QuizID:     session.QuizID,
+		SessionID:  session.ID,
+		QuestionID: d.QuestionID,
+		Score:      0,
+		TimeSpent:  int(d.Limit.Seconds()),
+	}
+	err = s.recordResponse(session, response, d.Index, func(progress *models.UserQuizProgress) {
+		progress.Streak = 0
+		judgeSurvival(session, progress, d.Index, false)
+	})
+	if err != nil {
+		// An answer that arrived in the grace period already moved the player on.
+		log.Printf("Not recording timeout for user %d: %v", userID, err)
+		return
+	}
+
+	s.addScore(session, userID, 0)
+	s.wsHub.SendMessageToUser(userID, "question_timeout", QuestionTimeoutMessage{
+		QuestionID: d.QuestionID,
+		Index:      d.Index,
+	})
+	s.afterResponse(session, userID, d.QuestionID, d.Index)
+}
+
+func (s *Service) HandleNextQuestionForUser(userID uint, code string, nextIndex int) error {
+    log.Printf("Handling next question for user %d in %s, next index: %d", userID, code, nextIndex)
     
-    quiz, err := s.GetQuizByCode(quizCode)
+    session, err := s.ResolveSession(code)
     if err != nil {
-        log.Printf("Error getting quiz: %v", err)
+        log.Printf("Error getting session: %v", err)
         return err
     }
+    quizCode := session.JoinCode
 
-    questions, err := s.repo.GetQuizQuestions(quiz.ID)
+    questions, err := s.sessionQuestions(session)
     if err != nil {
         log.Printf("Error getting questions: %v", err)
         return err
     }
     totalQuestions := len(questions)
-    log.Printf("Total questions for quiz %s: %d", quizCode, totalQuestions)
+    log.Printf("Total questions for session %s: %d", quizCode, totalQuestions)
 
     // Check if the user is host; if so, skip sending question.
-    isHost, err := s.repo.IsUserHost(quiz.ID, userID)
-    if err != nil {
-        log.Printf("Error checking host status: %v", err)
-        isHost = false
-    }
-    if isHost {
+    if session.HostID == userID {
         log.Printf("User %d is host; skipping sending question.", userID)
         return nil
     }
+    if err := requireState(session, "send the next question", models.SessionInProgress); err != nil {
+        return err
+    }
+    if !session.IsSelfPaced() {
+        return ErrWrongPacing
+    }
 
     if nextIndex >= totalQuestions {
         log.Printf("User %d has finished quiz %s", userID, quizCode)
 
-        finishedCount, err := s.repo.GetFinishedPlayersCount(quiz.ID, totalQuestions)
-        if err != nil {
-            return err
-        }
-        totalParticipants, err := s.repo.GetUniqueParticipantsForQuiz(quiz.ID)
+        playing, err := s.playersStillPlaying(session, totalQuestions)
         if err != nil {
             return err
         }
-        log.Printf("Finished count: %d, Total participants: %d", finishedCount, totalParticipants)
+        log.Printf("Players still playing quiz %s: %d", quizCode, playing)
 
-        if finishedCount >= totalParticipants {
+        if playing == 0 {
             log.Printf("All participants finished quiz %s. Broadcasting final leaderboard.", quizCode)
-            if err := s.updateLeaderboard(quiz.ID); err != nil {
-                log.Printf("Error updating leaderboard: %v", err)
-            }
-            leaderboard, err := s.cache.GetLeaderboard(quiz.QuizCode)
-            if err != nil {
-                log.Printf("Error retrieving leaderboard from cache: %v", err)
+            if err := s.endSession(session); err != nil {
+                // Another player's last answer already finished the session.
+                log.Printf("Session %s already finished: %v", quizCode, err)
+                return nil
             }
-            s.wsHub.BroadcastMessage(quizCode, "final_leaderboard", leaderboard)
         } else {
             log.Printf("User %d finished, waiting for others in quiz %s", userID, quizCode)
-            s.wsHub.SendMessageToUser(userID, "quiz_end_wait", map[string]string{
-                "message": "You have finished the quiz. Please wait for other players to finish.",
+            s.wsHub.SendMessageToUser(userID, "quiz_end_wait", QuizEndWaitMessage{
+                Message: "You have finished the quiz. Please wait for other players to finish.",
             })
         }
         return nil
@@ -356,44 +683,40 @@ func (s *Service) HandleNextQuestionForUser(userID uint, quizCode string, nextIn
 
     // Otherwise, send the next question only to this participant.
     nextQuestion := questions[nextIndex]
-    messageData := map[string]interface{}{
-        "question": nextQuestion.ToDTO(false),
-        "index":    nextIndex,
-        "total":    totalQuestions,
-        "quizId":   quiz.ID,
-    }
+    deadline := s.armQuestion(session, userID, nextQuestion, nextIndex, time.Now())
+    messageData := questionMessage(session, nextQuestion, nextIndex, totalQuestions, deadline.ExpiresAt(), false)
 
     s.wsHub.SendMessageToUser(userID, "question", messageData)
     return nil
 }
 
+// broadcastQuestion sends a question to the whole room; only hosts receive
+// the variant carrying the correct answer.
+func (s *Service) broadcastQuestion(session *models.QuizSession, question models.Question, index, total int, expiresAt time.Time) {
+	s.wsHub.BroadcastByRole(session.JoinCode, "question",
+		questionMessage(session, question, index, total, expiresAt, true),
+		questionMessage(session, question, index, total, expiresAt, false),
+	)
+}
 
+// questionMessage builds the payload of a "question" message for a host or a player.
+func questionMessage(session *models.QuizSession, question models.Question, index, total int, expiresAt time.Time, isHost bool) QuestionMessage {
+	return QuestionMessage{
+		Question:  question.ToDTO(isHost),
+		Index:     index,
+		Total:     total,
+		QuizID:    session.QuizID,
+		SessionID: session.ID,
+		Deadline:  expiresAt.UnixMilli(),
+		Revive:    session.Survival.IsReviveRound(index),
+	}
+}
 
 
 
 
 
-func (s *Service) updateLeaderboard(quizID uint) error {
-    entries, err := s.repo.GetLeaderboard(quizID)
-    if err != nil {
-        return err
-    }
 
-    quiz, err := s.repo.GetQuizByID(quizID)
-    if err != nil {
-        return err
-    }
-
-    // Convert entries to map[string]int format for cache
-    scores := make(map[string]int)
-    for _, entry := range entries {
-        scores[entry.Username] = entry.TotalScore
-    }
-
-    log.Printf("%v scores of the players", scores)
-
-    return s.cache.SetLeaderboard(quiz.QuizCode, scores)
-}
 
 func generateQuizCode() string {
 	const charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
@@ -404,17 +727,3 @@ func generateQuizCode() string {
 	return string(code)
 }
 
-func calculateScore(answer, correctAnswer string, timeSpent int) int {
-    log.Printf("Calculating score. Answer: %q, Correct: %q, Time Spent: %d", answer, correctAnswer, timeSpent)
-    if answer != correctAnswer {
-        return 0
-    }
-    score := 1000
-    timeDeduction := timeSpent * 10
-    score -= timeDeduction
-    if score < 0 {
-        score = 0
-    }
-    return score
-}
-
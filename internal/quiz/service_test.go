@@ -0,0 +1,294 @@
+// backend/internal/quiz/service_test.go
+package quiz
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"quiz-system/internal/models"
+	"quiz-system/pkg/cache"
+	"quiz-system/pkg/websocket"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestService returns a service backed by a fresh database and Redis, on a
+// hub nobody is connected to. Transactions take the database's write lock
+// when they begin, which serializes them the way row locks do on Postgres.
+func newTestService(t *testing.T) (*Service, *gorm.DB) {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "quiz.db") + "?_busy_timeout=5000&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{TranslateError: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := models.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	hub := websocket.NewHub()
+	service := NewService(NewRepository(db), cache.NewRedisCache(miniredis.RunT(t).Addr()), hub)
+	hub.SetQuizService(service)
+	return service, db
+}
+
+// game is a session of a two-question, host-paced quiz with two players in
+// its lobby.
+type game struct {
+	service   *Service
+	db        *gorm.DB
+	session   *models.QuizSession
+	questions []models.Question
+	host      uint
+	alice     uint
+	bob       uint
+}
+
+func newGame(t *testing.T) *game {
+	t.Helper()
+	service, db := newTestService(t)
+	g := &game{service: service, db: db}
+
+	users := []models.User{
+		{Username: "host", Email: "host@example.com", Password: "x"},
+		{Username: "alice", Email: "alice@example.com", Password: "x"},
+		{Username: "bob", Email: "bob@example.com", Password: "x"},
+	}
+	if err := db.Create(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	g.host, g.alice, g.bob = users[0].ID, users[1].ID, users[2].ID
+
+	quiz := models.Quiz{
+		Title:     "Capitals",
+		CreatorID: g.host,
+		QuizCode:  "CAPS01",
+		Pacing:    models.PacingHost,
+		Questions: []models.Question{
+			capitalQuestion(0, "France", "Paris", "Lyon"),
+			capitalQuestion(1, "Italy", "Rome", "Milan"),
+		},
+	}
+	if err := db.Create(&quiz).Error; err != nil {
+		t.Fatal(err)
+	}
+	g.questions = quiz.Questions
+
+	session, err := service.CreateSession(quiz.QuizCode, g.host, SessionSettings{})
+	if err != nil {
+		t.Fatalf("CreateSession() = %v", err)
+	}
+	if _, err := service.OpenSession(session.JoinCode, g.host); err != nil {
+		t.Fatalf("OpenSession() = %v", err)
+	}
+	for _, userID := range []uint{g.alice, g.bob} {
+		if _, err := service.JoinQuiz(session.JoinCode, userID); err != nil {
+			t.Fatalf("JoinQuiz(%d) = %v", userID, err)
+		}
+	}
+	g.session = session
+	t.Cleanup(func() { service.clock.forget(session.JoinCode) })
+	return g
+}
+
+func capitalQuestion(position int, country, capital, other string) models.Question {
+	return models.Question{
+		Position:      position,
+		Type:          models.QuestionSingleChoice,
+		Text:          fmt.Sprintf("What is the capital of %s?", country),
+		CorrectAnswer: capital,
+		TimeLimit:     30,
+		Options:       []models.Option{{Position: 0, Text: capital}, {Position: 1, Text: other}},
+	}
+}
+
+// start starts the game, which sends the first question.
+func (g *game) start(t *testing.T) {
+	t.Helper()
+	if _, err := g.service.StartQuiz(g.session.JoinCode, g.host); err != nil {
+		t.Fatalf("StartQuiz() = %v", err)
+	}
+}
+
+// answer submits the player's answer to the question at index.
+func (g *game) answer(userID uint, index int, answer, key string) (int, error) {
+	return g.service.ProcessAnswer(&models.UserQuizResponse{
+		SessionID:      g.session.ID,
+		UserID:         userID,
+		QuestionID:     g.questions[index].ID,
+		Answer:         answer,
+		IdempotencyKey: key,
+	})
+}
+
+// responses counts the answers recorded for the player.
+func (g *game) responses(t *testing.T, userID uint) int64 {
+	t.Helper()
+	var count int64
+	if err := g.db.Model(&models.UserQuizResponse{}).
+		Where("session_id = ? AND user_id = ?", g.session.ID, userID).
+		Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestProcessAnswer(t *testing.T) {
+	t.Run("correct answer scores", func(t *testing.T) {
+		g := newGame(t)
+		g.start(t)
+		score, err := g.answer(g.alice, 0, "Paris", "")
+		if err != nil || score <= 0 {
+			t.Fatalf("answer = %d, %v, want points", score, err)
+		}
+		score, err = g.answer(g.bob, 0, "Lyon", "")
+		if err != nil || score != 0 {
+			t.Errorf("wrong answer = %d, %v, want no points", score, err)
+		}
+	})
+
+	t.Run("before the quiz starts", func(t *testing.T) {
+		g := newGame(t)
+		if _, err := g.answer(g.alice, 0, "Paris", ""); !errors.Is(err, ErrInvalidState) {
+			t.Errorf("answer in the lobby = %v, want ErrInvalidState", err)
+		}
+	})
+
+	t.Run("question not sent yet", func(t *testing.T) {
+		g := newGame(t)
+		g.start(t)
+		if _, err := g.answer(g.alice, 1, "Rome", ""); !errors.Is(err, ErrNoActiveQuestion) {
+			t.Errorf("answer to the next question = %v, want ErrNoActiveQuestion", err)
+		}
+		if n := g.responses(t, g.alice); n != 0 {
+			t.Errorf("%d answers recorded, want none", n)
+		}
+	})
+
+	t.Run("second answer is refused", func(t *testing.T) {
+		g := newGame(t)
+		g.start(t)
+		if _, err := g.answer(g.alice, 0, "Lyon", ""); err != nil {
+			t.Fatalf("first answer = %v", err)
+		}
+		if _, err := g.answer(g.alice, 0, "Paris", ""); !errors.Is(err, ErrAlreadyAnswered) {
+			t.Errorf("second answer = %v, want ErrAlreadyAnswered", err)
+		}
+		if n := g.responses(t, g.alice); n != 1 {
+			t.Errorf("%d answers recorded, want 1", n)
+		}
+	})
+
+	t.Run("retry with the same key replays the score", func(t *testing.T) {
+		g := newGame(t)
+		g.start(t)
+		first, err := g.answer(g.alice, 0, "Paris", "req-1")
+		if err != nil {
+			t.Fatalf("first answer = %v", err)
+		}
+		// The retry is answered from the original request even if its body
+		// differs.
+		retry, err := g.answer(g.alice, 0, "Lyon", "req-1")
+		if err != nil || retry != first {
+			t.Errorf("retry = %d, %v, want %d", retry, err, first)
+		}
+		if n := g.responses(t, g.alice); n != 1 {
+			t.Errorf("%d answers recorded, want 1", n)
+		}
+		// Another request is a second answer.
+		if _, err := g.answer(g.alice, 0, "Paris", "req-2"); !errors.Is(err, ErrAlreadyAnswered) {
+			t.Errorf("answer with a new key = %v, want ErrAlreadyAnswered", err)
+		}
+	})
+
+	t.Run("keys are per player", func(t *testing.T) {
+		g := newGame(t)
+		g.start(t)
+		if _, err := g.answer(g.alice, 0, "Paris", "req-1"); err != nil {
+			t.Fatalf("alice's answer = %v", err)
+		}
+		score, err := g.answer(g.bob, 0, "Lyon", "req-1")
+		if err != nil || score != 0 {
+			t.Errorf("bob's answer = %d, %v, want their own result", score, err)
+		}
+		if n := g.responses(t, g.bob); n != 1 {
+			t.Errorf("%d answers recorded for bob, want 1", n)
+		}
+	})
+
+	t.Run("after the time limit", func(t *testing.T) {
+		g := newGame(t)
+		g.start(t)
+		// Alice was sent the question longer ago than its limit and the
+		// grace period allow.
+		question := g.questions[0]
+		g.service.clock.start(g.session.JoinCode, g.alice, g.session.ID, question, 0, almostDue(question, -time.Second))
+		if _, err := g.answer(g.alice, 0, "Paris", ""); !errors.Is(err, ErrAnswerTooLate) {
+			t.Errorf("late answer = %v, want ErrAnswerTooLate", err)
+		}
+	})
+
+	t.Run("question of another quiz", func(t *testing.T) {
+		g := newGame(t)
+		g.start(t)
+		other := models.Quiz{Title: "Other", CreatorID: g.host, QuizCode: "OTHER1",
+			Questions: []models.Question{capitalQuestion(0, "Spain", "Madrid", "Seville")}}
+		if err := g.db.Create(&other).Error; err != nil {
+			t.Fatal(err)
+		}
+		_, err := g.service.ProcessAnswer(&models.UserQuizResponse{
+			SessionID:  g.session.ID,
+			UserID:     g.alice,
+			QuestionID: other.Questions[0].ID,
+			Answer:     "Madrid",
+		})
+		if !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("answer = %v, want ErrInvalidInput", err)
+		}
+	})
+}
+
+func TestProcessAnswerConcurrently(t *testing.T) {
+	g := newGame(t)
+	g.start(t)
+
+	const submissions = 8
+	scores := make(chan int, submissions)
+	errs := make(chan error, submissions)
+	for i := 0; i < submissions; i++ {
+		go func(i int) {
+			score, err := g.answer(g.alice, 0, "Paris", fmt.Sprintf("req-%d", i))
+			scores <- score
+			errs <- err
+		}(i)
+	}
+
+	accepted := 0
+	for i := 0; i < submissions; i++ {
+		<-scores
+		switch err := <-errs; {
+		case err == nil:
+			accepted++
+		case errors.Is(err, ErrAlreadyAnswered), errors.Is(err, ErrNoActiveQuestion):
+		default:
+			t.Errorf("answer = %v", err)
+		}
+	}
+	if accepted != 1 {
+		t.Errorf("%d submissions accepted, want 1", accepted)
+	}
+	if n := g.responses(t, g.alice); n != 1 {
+		t.Errorf("%d answers recorded, want 1", n)
+	}
+	progress, err := g.service.repo.GetUserProgress(g.alice, g.session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.NextIndex != 1 || progress.Streak != 1 {
+		t.Errorf("progress = index %d, streak %d, want 1, 1", progress.NextIndex, progress.Streak)
+	}
+}
@@ -0,0 +1,227 @@
+// backend/internal/quiz/session.go
+package quiz
+
+import (
+	"errors"
+	"log"
+	"math/rand"
+	"quiz-system/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// sessionCodeLength is the length of session join codes.
+const sessionCodeLength = 8
+
+// CreateSession creates a new run of a quiz as a draft; the host opens its
+// lobby once it is set up. Only the quiz creator may host it.
+func (s *Service) CreateSession(quizCode string, userID uint, settings SessionSettings) (*models.QuizSession, error) {
+	quiz, err := s.authorizeCreator(quizCode, userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Scoring != nil {
+		if err := validateScoring(*settings.Scoring); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateTeams(settings.TeamMode, settings.TeamScoring, settings.Teams); err != nil {
+		return nil, err
+	}
+	if settings.Survival != nil {
+		if err := validateSurvival(*settings.Survival, quiz.Pacing); err != nil {
+			return nil, err
+		}
+	}
+	return s.newSession(quiz, models.SessionDraft, func(session *models.QuizSession) {
+		session.ShuffleQuestions = settings.ShuffleQuestions
+		session.ShuffleOptions = settings.ShuffleOptions
+		if settings.Seed != nil {
+			session.ShuffleSeed = *settings.Seed
+		}
+		if settings.Scoring != nil {
+			session.Scoring = *settings.Scoring
+		}
+		if settings.TeamMode != "" {
+			session.TeamMode = settings.TeamMode
+		}
+		if settings.TeamScoring != "" {
+			session.TeamScoring = settings.TeamScoring
+		}
+		session.Teams = newTeams(settings.Teams)
+		if settings.Survival != nil {
+			session.Survival = *settings.Survival
+		}
+	})
+}
+
+// GetSessions lists every run of a quiz, newest first.
+func (s *Service) GetSessions(quizCode string, userID uint) ([]models.QuizSession, error) {
+	quiz, err := s.authorizeCreator(quizCode, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetSessionsByQuiz(quiz.ID)
+}
+
+// GetSession returns the session a code refers to without opening a new one.
+func (s *Service) GetSession(code string) (*models.QuizSession, error) {
+	return s.findSession(code, false)
+}
+
+// ParticipantIDs returns the players who have joined the session of a code.
+func (s *Service) ParticipantIDs(code string) ([]uint, error) {
+	session, err := s.ResolveSession(code)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetParticipantIDs(session.ID)
+}
+
+// ResolveSession returns the session players of a code play in: the session
+// itself for a join code, or the quiz's current (not yet finished) session for
+// a quiz code. It never opens a session; a quiz code without a current session
+// fails with gorm.ErrRecordNotFound.
+func (s *Service) ResolveSession(code string) (*models.QuizSession, error) {
+	return s.findSession(code, true)
+}
+
+// findSession looks a code up as a session join code or, failing that, as a
+// quiz code. For a quiz code it returns the current (not yet finished) session
+// when current is set, and otherwise the latest session in any state.
+func (s *Service) findSession(code string, current bool) (*models.QuizSession, error) {
+	session, err := s.repo.GetSessionByJoinCode(code)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return session, err
+	}
+
+	quiz, err := s.GetQuizByCode(code)
+	if err != nil {
+		return nil, err
+	}
+	if !current {
+		return s.repo.GetLatestSession(quiz.ID)
+	}
+	return s.repo.GetOpenSession(quiz.ID)
+}
+
+// sessionToStart returns the session the host of a code starts. Starting a
+// quiz by its quiz code when it has no current session opens a lobby instead,
+// which is reported by opened; players join it and the host starts it next.
+// This and CreateSession are the only ways a session is opened.
+func (s *Service) sessionToStart(code string, userID uint) (session *models.QuizSession, opened bool, err error) {
+	session, err = s.authorizeHost(code, userID)
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return session, false, err
+	}
+
+	quiz, err := s.authorizeCreator(code, userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+
+	session, err = s.repo.GetOpenSession(quiz.ID)
+	if err == nil {
+		return session, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+	log.Printf("Host %d is starting quiz %s without a session; opening its lobby", userID, code)
+	session, err = s.newSession(quiz, models.SessionLobby)
+	return session, err == nil, err
+}
+
+// newSession creates a session of the quiz in the given state, scored and
+// paced the way the quiz currently is. Each configure function may adjust the session before
+// it is saved.
+func (s *Service) newSession(quiz *models.Quiz, state models.SessionState, configure ...func(*models.QuizSession)) (*models.QuizSession, error) {
+	session := &models.QuizSession{
+		QuizID:         quiz.ID,
+		HostID:         quiz.CreatorID,
+		JoinCode:       generateSessionCode(),
+		State:          state,
+		ShuffleSeed:    rand.Int63(),
+		Scoring:        quiz.Scoring,
+		Pacing:         quiz.Pacing,
+		SelfPacedLimit: quiz.SelfPacedLimit,
+		TeamMode:       models.TeamsNone,
+		TeamScoring:    models.TeamScoreSum,
+	}
+	if session.Pacing == "" {
+		session.Pacing = models.PacingHost
+	}
+	for _, apply := range configure {
+		apply(session)
+	}
+	if err := s.repo.CreateSession(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// authorizeHost resolves the session and checks that userID is its host.
+// Every host action on a live game goes through it.
+func (s *Service) authorizeHost(code string, userID uint) (*models.QuizSession, error) {
+	session, err := s.ResolveSession(code)
+	if err != nil {
+		return nil, err
+	}
+	if session.HostID != userID {
+		log.Printf("User %d is not the host of session %s", userID, session.JoinCode)
+		return nil, ErrNotHost
+	}
+	return session, nil
+}
+
+// authorizeCreator loads the quiz and checks that userID created it.
+func (s *Service) authorizeCreator(quizCode string, userID uint) (*models.Quiz, error) {
+	quiz, err := s.GetQuizByCode(quizCode)
+	if err != nil {
+		return nil, err
+	}
+	if quiz.CreatorID != userID {
+		log.Printf("User %d is not the creator of quiz %s", userID, quizCode)
+		return nil, ErrNotHost
+	}
+	return quiz, nil
+}
+
+// finishSession ends a session, stops its timers, reconciles its live
+// leaderboard with the database and clears the quiz's active flag. It fails
+// with a StateError if the session already finished.
+func (s *Service) finishSession(session *models.QuizSession) error {
+	s.clock.clear(session.JoinCode)
+
+	if err := s.transition(session, "finish the session", models.SessionFinished); err != nil {
+		return err
+	}
+	if err := s.updateLeaderboard(session); err != nil {
+		log.Printf("Error updating leaderboard: %v", err)
+	}
+	s.leaderboards.forget(session.JoinCode)
+	s.setQuizActive(session.QuizID, false)
+	return nil
+}
+
+func (s *Service) setQuizActive(quizID uint, active bool) {
+	if err := s.repo.SetQuizActive(quizID, active); err != nil {
+		log.Printf("Error updating quiz %d status: %v", quizID, err)
+		return
+	}
+	if quiz, err := s.repo.GetQuizByID(quizID); err == nil {
+		s.cache.DeleteQuiz(quiz.QuizCode)
+	}
+}
+
+func generateSessionCode() string {
+	const charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	code := make([]byte, sessionCodeLength)
+	for i := range code {
+		code[i] = charset[rand.Intn(len(charset))]
+	}
+	return string(code)
+}
@@ -0,0 +1,82 @@
+// backend/internal/quiz/session_test.go
+package quiz
+
+import (
+	"errors"
+	"quiz-system/internal/models"
+	"testing"
+)
+
+func TestHostActionsRequireTheHost(t *testing.T) {
+	drop := func(_ *models.QuizSession, err error) error { return err }
+	actions := []struct {
+		name string
+		// started is whether the action is tried on a game in progress
+		// rather than in the lobby.
+		started bool
+		run     func(s *Service, code string, userID uint) error
+	}{
+		{"start", false, func(s *Service, code string, userID uint) error { return drop(s.StartQuiz(code, userID)) }},
+		{"end from the lobby", false, func(s *Service, code string, userID uint) error { return drop(s.EndSession(code, userID)) }},
+		{"next question", true, func(s *Service, code string, userID uint) error { return s.HandleNextQuestion(code, userID, 0) }},
+		{"reveal", true, func(s *Service, code string, userID uint) error { return s.RevealResults(code, userID) }},
+		{"pause", true, func(s *Service, code string, userID uint) error { return drop(s.PauseSession(code, userID)) }},
+		{"skip", true, func(s *Service, code string, userID uint) error { return drop(s.SkipQuestion(code, userID)) }},
+		{"end", true, func(s *Service, code string, userID uint) error { return drop(s.EndSession(code, userID)) }},
+	}
+
+	for _, action := range actions {
+		t.Run(action.name, func(t *testing.T) {
+			g := newGame(t)
+			want := models.SessionLobby
+			if action.started {
+				g.start(t)
+				want = models.SessionInProgress
+			}
+
+			// A player, and a user who never joined, are refused.
+			for _, userID := range []uint{g.alice, 999} {
+				if err := action.run(g.service, g.session.JoinCode, userID); !errors.Is(err, ErrNotHost) {
+					t.Errorf("%s by user %d = %v, want ErrNotHost", action.name, userID, err)
+				}
+			}
+			if state := g.stored(t); state != want {
+				t.Errorf("session is %s after refused actions, want %s", state, want)
+			}
+			if err := action.run(g.service, g.session.JoinCode, g.host); err != nil {
+				t.Errorf("%s by the host = %v", action.name, err)
+			}
+		})
+	}
+}
+
+func TestHostOnlyActionsOnAPausedGame(t *testing.T) {
+	g := newGame(t)
+	g.start(t)
+	if _, err := g.service.PauseSession(g.session.JoinCode, g.alice); !errors.Is(err, ErrNotHost) {
+		t.Errorf("pause by a player = %v, want ErrNotHost", err)
+	}
+	if _, err := g.service.PauseSession(g.session.JoinCode, g.host); err != nil {
+		t.Fatalf("PauseSession() = %v", err)
+	}
+	if _, err := g.service.ResumeSession(g.session.JoinCode, g.alice); !errors.Is(err, ErrNotHost) {
+		t.Errorf("resume by a player = %v, want ErrNotHost", err)
+	}
+	if state := g.stored(t); state != models.SessionPaused {
+		t.Errorf("session is %s, want paused", state)
+	}
+}
+
+func TestOnlyTheCreatorHostsSessions(t *testing.T) {
+	g := newGame(t)
+	quiz, err := g.service.GetQuizByCode("CAPS01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.service.CreateSession(quiz.QuizCode, g.alice, SessionSettings{}); !errors.Is(err, ErrNotHost) {
+		t.Errorf("CreateSession() by a player = %v, want ErrNotHost", err)
+	}
+	if _, err := g.service.GetSessions(quiz.QuizCode, g.alice); !errors.Is(err, ErrNotHost) {
+		t.Errorf("GetSessions() by a player = %v, want ErrNotHost", err)
+	}
+}
@@ -0,0 +1,37 @@
+// backend/internal/quiz/spectator.go
+package quiz
+
+import (
+	"errors"
+	"log"
+	"time"
+)
+
+// ScreenTokenIssuer mints the screen token of a session, returning it and
+// when it expires.
+type ScreenTokenIssuer func(joinCode string) (string, time.Time, error)
+
+// SetScreenTokenIssuer configures how screen tokens are minted. Without one
+// the host cannot hand out spectator access.
+func (s *Service) SetScreenTokenIssuer(issue ScreenTokenIssuer) {
+	s.issueScreenToken = issue
+}
+
+// ScreenToken mints a read-only token for a display, such as a classroom
+// projector, to watch the session as a spectator. Only the host can mint one.
+func (s *Service) ScreenToken(code string, userID uint) (string, time.Time, error) {
+	session, err := s.authorizeHost(code, userID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if s.issueScreenToken == nil {
+		return "", time.Time{}, errors.New("screen tokens are not configured")
+	}
+
+	token, expiresAt, err := s.issueScreenToken(session.JoinCode)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	log.Printf("Host %d minted a screen token for session %s", userID, session.JoinCode)
+	return token, expiresAt, nil
+}
@@ -0,0 +1,233 @@
+// backend/internal/quiz/survival.go
+package quiz
+
+import (
+	"fmt"
+	"log"
+	"quiz-system/internal/models"
+	"time"
+)
+
+// SurvivalPlayer names a player in the messages of a survival game.
+type SurvivalPlayer struct {
+	UserID   uint   `json:"userId"`
+	Username string `json:"username"`
+}
+
+// validateSurvival checks the survival settings of a new session. Survival
+// games need every player on the same question, so they are host-paced.
+func validateSurvival(config models.SurvivalConfig, pacing models.PacingMode) error {
+	if !config.Enabled {
+		return nil
+	}
+	if pacing == models.PacingSelf {
+		return fmt.Errorf("%w: survival games must be host-paced", ErrInvalidInput)
+	}
+	if config.ReviveEvery < 0 {
+		return fmt.Errorf("%w: revive_every cannot be negative", ErrInvalidInput)
+	}
+	return nil
+}
+
+// survives reports whether an answer keeps a player in a survival game: it
+// must be fully correct and, if the game sets an answer time, given within it.
+func survives(config models.SurvivalConfig, credit float64, elapsed time.Duration) bool {
+	if credit < 1 {
+		return false
+	}
+	return config.AnswerTime == 0 || elapsed <= time.Duration(config.AnswerTime)*time.Second
+}
+
+// judgeSurvival records what a response to the question at index means for
+// the player: a player who is in is out unless they survived it, and a player
+// who is out is back in if they survived a revive round. It runs while the
+// player's progress is locked.
+func judgeSurvival(session *models.QuizSession, progress *models.UserQuizProgress, index int, survived bool) {
+	if !session.Survival.Enabled {
+		return
+	}
+	switch {
+	case !progress.Eliminated && !survived:
+		progress.Eliminated = true
+		progress.EliminatedOn = &index
+		log.Printf("User %d is out of session %s on question %d", progress.UserID, session.JoinCode, index)
+	case progress.Eliminated && survived && session.Survival.IsReviveRound(index):
+		progress.Eliminated = false
+		progress.RevivedOn = &index
+		log.Printf("User %d is back in session %s on question %d", progress.UserID, session.JoinCode, index)
+	}
+}
+
+// requireContender fails with ErrEliminated if the player is out of a
+// survival game and its current question is not a revive round.
+func (s *Service) requireContender(session *models.QuizSession, userID uint) error {
+	if !session.Survival.Enabled || session.Survival.IsReviveRound(session.CurrentIndex) {
+		return nil
+	}
+	eliminated, err := s.isEliminated(session, userID)
+	if err != nil {
+		return err
+	}
+	if eliminated {
+		return ErrEliminated
+	}
+	return nil
+}
+
+// isEliminated reports whether the player is out of a survival game.
+func (s *Service) isEliminated(session *models.QuizSession, userID uint) (bool, error) {
+	if !session.Survival.Enabled {
+		return false, nil
+	}
+	eliminated, err := s.repo.GetEliminatedIDs(session.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range eliminated {
+		if id == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// contenders returns the players who may answer the question at index: in a
+// survival game, those still in it, or everyone in a revive round.
+func (s *Service) contenders(session *models.QuizSession, index int, userIDs []uint) []uint {
+	if !session.Survival.Enabled || session.Survival.IsReviveRound(index) {
+		return userIDs
+	}
+	eliminated, err := s.repo.GetEliminatedIDs(session.ID)
+	if err != nil {
+		log.Printf("Error listing eliminated players of session %s: %v", session.JoinCode, err)
+		return userIDs
+	}
+	out := make(map[uint]bool, len(eliminated))
+	for _, id := range eliminated {
+		out[id] = true
+	}
+	remaining := make([]uint, 0, len(userIDs))
+	for _, id := range userIDs {
+		if !out[id] {
+			remaining = append(remaining, id)
+		}
+	}
+	return remaining
+}
+
+// eliminateLateJoiner puts a player who joins a survival game after it
+// started out straight away; they follow the rest of it.
+func (s *Service) eliminateLateJoiner(session *models.QuizSession, userID uint, joined bool) {
+	if !session.Survival.Enabled || joined {
+		return
+	}
+	if session.State != models.SessionInProgress && session.State != models.SessionPaused {
+		return
+	}
+	if err := s.repo.EliminatePlayer(session, userID); err != nil {
+		log.Printf("Error eliminating late joiner %d of session %s: %v", userID, session.JoinCode, err)
+		return
+	}
+	log.Printf("User %d joined survival session %s after it started and follows along", userID, session.JoinCode)
+}
+
+// announceEliminations tells the room who the question at index knocked out
+// and who it brought back. If it would knock out every player left, nobody
+// is out.
+func (s *Service) announceEliminations(session *models.QuizSession, question models.Question, index int) {
+	if !session.Survival.Enabled {
+		return
+	}
+	eliminated, err := s.repo.GetEliminatedOn(session.ID, index)
+	if err != nil {
+		log.Printf("Error listing players knocked out of session %s: %v", session.JoinCode, err)
+		return
+	}
+	survivors, err := s.repo.GetSurvivors(session.ID)
+	if err != nil {
+		log.Printf("Error listing survivors of session %s: %v", session.JoinCode, err)
+		return
+	}
+	if len(survivors) == 0 && len(eliminated) > 0 {
+		log.Printf("Question %d knocked out every player left in session %s; keeping them all in", index, session.JoinCode)
+		if err := s.repo.ReinstatePlayers(session.ID, index); err != nil {
+			log.Printf("Error reinstating players of session %s: %v", session.JoinCode, err)
+			return
+		}
+		survivors, eliminated = eliminated, nil
+	}
+	revived, err := s.repo.GetRevivedOn(session.ID, index)
+	if err != nil {
+		log.Printf("Error listing players revived in session %s: %v", session.JoinCode, err)
+		return
+	}
+
+	log.Printf("Question %d of session %s: %d out, %d back, %d left", index, session.JoinCode, len(eliminated), len(revived), len(survivors))
+	s.wsHub.BroadcastMessage(session.JoinCode, "elimination", EliminationMessage{
+		SessionID:  session.ID,
+		QuestionID: question.ID,
+		Index:      index,
+		Eliminated: nonNil(eliminated),
+		Revived:    nonNil(revived),
+		Remaining:  len(survivors),
+	})
+}
+
+// closeSurvivalQuestion times out the players still on the current question
+// of a survival game whose results the host did not show, so nobody stays in
+// without answering, and announces who is out.
+func (s *Service) closeSurvivalQuestion(session *models.QuizSession, question models.Question) {
+	if !session.Survival.Enabled || session.Phase != models.PhaseQuestion {
+		return
+	}
+	for playerID, d := range s.clock.drain(session.JoinCode) {
+		s.handleQuestionTimeout(session.ID, playerID, d)
+	}
+	s.announceEliminations(session, question, session.CurrentIndex)
+}
+
+// survivalOver reports whether a survival game ends before the question at
+// nextIndex: when at most one player is left or there is no such question.
+// It sends the room the survivors when it does.
+func (s *Service) survivalOver(session *models.QuizSession, nextIndex, total int) bool {
+	if !session.Survival.Enabled {
+		return false
+	}
+	survivors, err := s.repo.GetSurvivors(session.ID)
+	if err != nil {
+		log.Printf("Error listing survivors of session %s: %v", session.JoinCode, err)
+		return false
+	}
+	if len(survivors) > 1 && nextIndex < total {
+		return false
+	}
+
+	log.Printf("Survival session %s is over with %d players left", session.JoinCode, len(survivors))
+	s.wsHub.BroadcastMessage(session.JoinCode, "survival_over", SurvivalOverMessage{
+		SessionID: session.ID,
+		Survivors: nonNil(survivors),
+	})
+	return true
+}
+
+// undoSurvival reverses what the question at index did to the players of a
+// survival game, for a skipped question.
+func (s *Service) undoSurvival(session *models.QuizSession, index int) {
+	if !session.Survival.Enabled {
+		return
+	}
+	if err := s.repo.ReinstatePlayers(session.ID, index); err != nil {
+		log.Printf("Error reinstating players of session %s: %v", session.JoinCode, err)
+	}
+	if err := s.repo.UnrevivePlayers(session.ID, index); err != nil {
+		log.Printf("Error undoing revivals in session %s: %v", session.JoinCode, err)
+	}
+}
+
+// nonNil returns players, or an empty list for nil, so messages carry [].
+func nonNil(players []SurvivalPlayer) []SurvivalPlayer {
+	if players == nil {
+		return []SurvivalPlayer{}
+	}
+	return players
+}
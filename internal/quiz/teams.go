@@ -0,0 +1,379 @@
+// backend/internal/quiz/teams.go
+package quiz
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"quiz-system/internal/models"
+	"sort"
+	"strings"
+)
+
+// TeamRoster is a team with the players in it.
+type TeamRoster struct {
+	ID      uint                `json:"id"`
+	Name    string              `json:"name"`
+	Members []models.TeamMember `json:"members"`
+}
+
+// validateTeams checks the team settings of a new session.
+func validateTeams(mode models.TeamMode, scoring models.TeamScoring, names []string) error {
+	switch mode {
+	case "", models.TeamsNone:
+		if len(names) > 0 {
+			return fmt.Errorf("%w: teams need a team mode", ErrInvalidInput)
+		}
+	case models.TeamsAssigned, models.TeamsSelfSelect, models.TeamsAuto:
+	default:
+		return fmt.Errorf("%w: unknown team mode %q", ErrInvalidInput, mode)
+	}
+	switch scoring {
+	case "", models.TeamScoreSum, models.TeamScoreAverage, models.TeamScoreBest:
+	default:
+		return fmt.Errorf("%w: unknown team scoring %q", ErrInvalidInput, scoring)
+	}
+
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return fmt.Errorf("%w: team names cannot be empty", ErrInvalidInput)
+		}
+		if seen[name] {
+			return fmt.Errorf("%w: duplicate team name %q", ErrInvalidInput, name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// newTeams returns the teams to create with a session.
+func newTeams(names []string) []models.Team {
+	teams := make([]models.Team, len(names))
+	for i, name := range names {
+		teams[i] = models.Team{Name: strings.TrimSpace(name)}
+	}
+	return teams
+}
+
+// requireTeams fails with ErrWrongTeamMode unless the session is played in
+// teams, in one of the given modes if any are given.
+func requireTeams(session *models.QuizSession, modes ...models.TeamMode) error {
+	if !session.HasTeams() {
+		return ErrWrongTeamMode
+	}
+	if len(modes) == 0 {
+		return nil
+	}
+	for _, mode := range modes {
+		if session.TeamMode == mode {
+			return nil
+		}
+	}
+	return ErrWrongTeamMode
+}
+
+// GetTeams lists the teams of a session and their players.
+func (s *Service) GetTeams(code string) ([]TeamRoster, error) {
+	session, err := s.GetSession(code)
+	if err != nil {
+		return nil, err
+	}
+	return s.teamRosters(session)
+}
+
+// CreateTeam adds a team to a session that has not started.
+func (s *Service) CreateTeam(code string, hostID uint, name string) (*models.Team, error) {
+	session, err := s.authorizeHost(code, hostID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireTeams(session); err != nil {
+		return nil, err
+	}
+	if err := requireState(session, "add a team", models.SessionDraft, models.SessionLobby); err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: team names cannot be empty", ErrInvalidInput)
+	}
+
+	team := &models.Team{SessionID: session.ID, Name: name}
+	if err := s.repo.CreateTeam(team); err != nil {
+		return nil, err
+	}
+	s.broadcastTeams(session)
+	return team, nil
+}
+
+// AssignTeam puts a player in a team, or out of every team for a nil teamID.
+// The host can move players in any team mode until the quiz starts.
+func (s *Service) AssignTeam(code string, hostID, userID uint, teamID *uint) error {
+	session, err := s.authorizeHost(code, hostID)
+	if err != nil {
+		return err
+	}
+	if err := requireTeams(session); err != nil {
+		return err
+	}
+	if err := requireState(session, "assign teams", models.SessionLobby); err != nil {
+		return err
+	}
+	if err := s.setTeam(session, userID, teamID); err != nil {
+		return err
+	}
+	log.Printf("Host %d moved user %d of session %s to team %v", hostID, userID, session.JoinCode, teamID)
+	s.broadcastTeams(session)
+	return nil
+}
+
+// ChooseTeam lets a player of a self-select session join a team in the lobby.
+func (s *Service) ChooseTeam(code string, userID, teamID uint) error {
+	session, err := s.GetSession(code)
+	if err != nil {
+		return err
+	}
+	if err := requireTeams(session, models.TeamsSelfSelect); err != nil {
+		return err
+	}
+	if err := requireState(session, "choose a team", models.SessionLobby); err != nil {
+		return err
+	}
+	if err := s.setTeam(session, userID, &teamID); err != nil {
+		return err
+	}
+	log.Printf("User %d of session %s chose team %d", userID, session.JoinCode, teamID)
+	s.broadcastTeams(session)
+	return nil
+}
+
+// BalanceTeams deals the players of a session out over its teams in random
+// order, so team sizes differ by at most one.
+func (s *Service) BalanceTeams(code string, hostID uint) ([]TeamRoster, error) {
+	session, err := s.authorizeHost(code, hostID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireTeams(session); err != nil {
+		return nil, err
+	}
+	if err := requireState(session, "balance teams", models.SessionLobby); err != nil {
+		return nil, err
+	}
+	teams, err := s.repo.GetTeams(session.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(teams) == 0 {
+		return nil, fmt.Errorf("%w: the session has no teams", ErrInvalidInput)
+	}
+	members, err := s.repo.GetTeamMembers(session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rand.Shuffle(len(members), func(i, j int) { members[i], members[j] = members[j], members[i] })
+	for i, member := range members {
+		teamID := teams[i%len(teams)].ID
+		if err := s.repo.SetParticipantTeam(session.ID, member.UserID, &teamID); err != nil {
+			return nil, err
+		}
+	}
+	log.Printf("Host %d balanced %d players of session %s over %d teams", hostID, len(members), session.JoinCode, len(teams))
+	s.broadcastTeams(session)
+	return s.teamRosters(session)
+}
+
+// setTeam moves a player to a team of the session.
+func (s *Service) setTeam(session *models.QuizSession, userID uint, teamID *uint) error {
+	if teamID != nil {
+		if _, err := s.repo.GetTeam(session.ID, *teamID); err != nil {
+			return err
+		}
+	}
+	return s.repo.SetParticipantTeam(session.ID, userID, teamID)
+}
+
+// placeInTeam puts a player who joined an auto-balanced session in its
+// smallest team, unless they are in a team already.
+func (s *Service) placeInTeam(session *models.QuizSession, userID uint) {
+	if session.TeamMode != models.TeamsAuto {
+		return
+	}
+	teams, err := s.repo.GetTeams(session.ID)
+	if err != nil || len(teams) == 0 {
+		return
+	}
+	members, err := s.repo.GetTeamMembers(session.ID)
+	if err != nil {
+		log.Printf("Error listing team members of session %s: %v", session.JoinCode, err)
+		return
+	}
+
+	sizes := make(map[uint]int, len(teams))
+	for _, member := range members {
+		if member.TeamID == nil {
+			continue
+		}
+		if member.UserID == userID {
+			return
+		}
+		sizes[*member.TeamID]++
+	}
+	smallest := teams[0].ID
+	for _, team := range teams[1:] {
+		if sizes[team.ID] < sizes[smallest] {
+			smallest = team.ID
+		}
+	}
+	if err := s.repo.SetParticipantTeam(session.ID, userID, &smallest); err != nil {
+		log.Printf("Error placing user %d in a team of session %s: %v", userID, session.JoinCode, err)
+		return
+	}
+	log.Printf("Placed user %d in team %d of session %s", userID, smallest, session.JoinCode)
+	s.broadcastTeams(session)
+}
+
+// teamRosters lists the teams of a session with their players.
+func (s *Service) teamRosters(session *models.QuizSession) ([]TeamRoster, error) {
+	teams, err := s.repo.GetTeams(session.ID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.repo.GetTeamMembers(session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rosters := make([]TeamRoster, len(teams))
+	index := make(map[uint]int, len(teams))
+	for i, team := range teams {
+		rosters[i] = TeamRoster{ID: team.ID, Name: team.Name, Members: []models.TeamMember{}}
+		index[team.ID] = i
+	}
+	for _, member := range members {
+		if member.TeamID == nil {
+			continue
+		}
+		if i, ok := index[*member.TeamID]; ok {
+			rosters[i].Members = append(rosters[i].Members, member)
+		}
+	}
+	return rosters, nil
+}
+
+// broadcastTeams sends the room the teams and their players.
+func (s *Service) broadcastTeams(session *models.QuizSession) {
+	rosters, err := s.teamRosters(session)
+	if err != nil {
+		log.Printf("Error listing teams of session %s: %v", session.JoinCode, err)
+		return
+	}
+	s.wsHub.BroadcastMessage(session.JoinCode, "teams", TeamsMessage{SessionID: session.ID, Teams: rosters})
+}
+
+// GetTeamLeaderboard returns the team scores of a session, best team first.
+func (s *Service) GetTeamLeaderboard(code string) ([]models.TeamLeaderboardEntry, error) {
+	session, err := s.GetSession(code)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireTeams(session); err != nil {
+		return nil, err
+	}
+	entries, err := s.repo.GetLeaderboard(session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.teamBoard(session, entries), nil
+}
+
+// teamBoard labels the entries with the teams of their players and ranks the
+// teams of the session by the scores of their members. It returns nil for a
+// session without teams.
+func (s *Service) teamBoard(session *models.QuizSession, entries []models.LeaderboardEntry) []models.TeamLeaderboardEntry {
+	if !session.HasTeams() {
+		return nil
+	}
+	teams, err := s.repo.GetTeams(session.ID)
+	if err != nil {
+		log.Printf("Error listing teams of session %s: %v", session.JoinCode, err)
+		return nil
+	}
+	members, err := s.repo.GetTeamMembers(session.ID)
+	if err != nil {
+		log.Printf("Error listing team members of session %s: %v", session.JoinCode, err)
+		return nil
+	}
+
+	teamOf := make(map[uint]uint, len(members))
+	for _, member := range members {
+		if member.TeamID != nil {
+			teamOf[member.UserID] = *member.TeamID
+		}
+	}
+	names := make(map[uint]string, len(teams))
+	for _, team := range teams {
+		names[team.ID] = team.Name
+	}
+	for i := range entries {
+		if teamID, ok := teamOf[entries[i].UserID]; ok {
+			id := teamID
+			entries[i].TeamID = &id
+			entries[i].TeamName = names[teamID]
+		}
+	}
+	return rankTeams(teams, teamOf, entries, session.TeamScoring)
+}
+
+// rankTeams scores each team from the scores of its members by the session's
+// rule and ranks them, best first. Members who have not scored count as zero.
+// Tied teams share a rank.
+func rankTeams(teams []models.Team, teamOf map[uint]uint, entries []models.LeaderboardEntry, rule models.TeamScoring) []models.TeamLeaderboardEntry {
+	scores := make(map[uint]int, len(entries))
+	for _, entry := range entries {
+		scores[entry.UserID] = entry.TotalScore
+	}
+	sums := make(map[uint]int, len(teams))
+	best := make(map[uint]int, len(teams))
+	sizes := make(map[uint]int, len(teams))
+	for userID, teamID := range teamOf {
+		score := scores[userID]
+		if sizes[teamID] == 0 || score > best[teamID] {
+			best[teamID] = score
+		}
+		sums[teamID] += score
+		sizes[teamID]++
+	}
+
+	board := make([]models.TeamLeaderboardEntry, len(teams))
+	for i, team := range teams {
+		score := sums[team.ID]
+		switch rule {
+		case models.TeamScoreAverage:
+			if sizes[team.ID] > 0 {
+				score = int(math.Round(float64(sums[team.ID]) / float64(sizes[team.ID])))
+			}
+		case models.TeamScoreBest:
+			score = best[team.ID]
+		}
+		board[i] = models.TeamLeaderboardEntry{
+			TeamID:  team.ID,
+			Name:    team.Name,
+			Score:   score,
+			Members: sizes[team.ID],
+		}
+	}
+
+	sort.SliceStable(board, func(i, j int) bool { return board[i].Score > board[j].Score })
+	for i := range board {
+		board[i].Rank = i + 1
+		if i > 0 && board[i].Score == board[i-1].Score {
+			board[i].Rank = board[i-1].Rank
+		}
+	}
+	return board
+}
@@ -0,0 +1,71 @@
+// backend/internal/quiz/teams_test.go
+package quiz
+
+import (
+	"quiz-system/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestRankTeams(t *testing.T) {
+	teams := []models.Team{{ID: 1, Name: "Red"}, {ID: 2, Name: "Blue"}, {ID: 3, Name: "Green"}}
+	// Red has three members, one of whom has not scored; Blue has two and
+	// Green none. User 99 plays without a team.
+	teamOf := map[uint]uint{10: 1, 11: 1, 12: 1, 20: 2, 21: 2}
+	entries := []models.LeaderboardEntry{
+		{UserID: 10, TotalScore: 300},
+		{UserID: 11, TotalScore: 100},
+		{UserID: 20, TotalScore: 250},
+		{UserID: 21, TotalScore: 250},
+		{UserID: 99, TotalScore: 900},
+	}
+
+	red := func(rank, score int) models.TeamLeaderboardEntry {
+		return models.TeamLeaderboardEntry{TeamID: 1, Name: "Red", Rank: rank, Score: score, Members: 3}
+	}
+	blue := func(rank, score int) models.TeamLeaderboardEntry {
+		return models.TeamLeaderboardEntry{TeamID: 2, Name: "Blue", Rank: rank, Score: score, Members: 2}
+	}
+	green := func(rank int) models.TeamLeaderboardEntry {
+		return models.TeamLeaderboardEntry{TeamID: 3, Name: "Green", Rank: rank, Score: 0, Members: 0}
+	}
+
+	tests := []struct {
+		name    string
+		rule    models.TeamScoring
+		entries []models.LeaderboardEntry
+		want    []models.TeamLeaderboardEntry
+	}{
+		{"sum", models.TeamScoreSum, entries, []models.TeamLeaderboardEntry{blue(1, 500), red(2, 400), green(3)}},
+		{"unset rule sums", "", entries, []models.TeamLeaderboardEntry{blue(1, 500), red(2, 400), green(3)}},
+		{"average counts members without a score", models.TeamScoreAverage, entries, []models.TeamLeaderboardEntry{blue(1, 250), red(2, 133), green(3)}},
+		{"best", models.TeamScoreBest, entries, []models.TeamLeaderboardEntry{red(1, 300), blue(2, 250), green(3)}},
+		{
+			"ties share a rank",
+			models.TeamScoreSum,
+			[]models.LeaderboardEntry{{UserID: 10, TotalScore: 200}, {UserID: 20, TotalScore: 200}},
+			[]models.TeamLeaderboardEntry{red(1, 200), blue(1, 200), green(3)},
+		},
+		{
+			"best counts members without a score as zero",
+			models.TeamScoreBest,
+			[]models.LeaderboardEntry{{UserID: 10, TotalScore: -50}, {UserID: 20, TotalScore: -20}, {UserID: 21, TotalScore: -10}},
+			[]models.TeamLeaderboardEntry{red(1, 0), green(1), blue(3, -10)},
+		},
+		{
+			"nobody scored",
+			models.TeamScoreAverage,
+			nil,
+			[]models.TeamLeaderboardEntry{red(1, 0), blue(1, 0), green(1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rankTeams(teams, teamOf, tt.entries, tt.rule)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rankTeams() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+// backend/internal/quiz/timer.go
+package quiz
+
+import (
+	"encoding/json"
+	"log"
+	"quiz-system/internal/models"
+	"quiz-system/pkg/cache"
+	"sync"
+	"time"
+)
+
+// answerGracePeriod absorbs the network latency between a player answering
+// on time and the answer reaching the server.
+const answerGracePeriod = 2 * time.Second
+
+// questionDeadline records when a question of a session was sent to a player
+// and how long they have to answer it.
+type questionDeadline struct {
+	SessionID  uint          `json:"session_id"`
+	QuestionID uint          `json:"question_id"`
+	Index      int           `json:"index"`
+	SentAt     time.Time     `json:"sent_at"`
+	Limit      time.Duration `json:"limit"`
+}
+
+// ExpiresAt is the moment the player's answer window closes.
+func (d questionDeadline) ExpiresAt() time.Time {
+	return d.SentAt.Add(d.Limit)
+}
+
+// Elapsed is the time the player has spent on the question, capped at the limit.
+func (d questionDeadline) Elapsed(now time.Time) time.Duration {
+	elapsed := now.Sub(d.SentAt)
+	if elapsed > d.Limit {
+		return d.Limit
+	}
+	return elapsed
+}
+
+// questionClock keeps the server-side timer of the question each player is
+// currently answering, keyed by join code and user. The deadlines are kept in
+// Redis, so every instance checks answers against the same ones; an instance
+// only holds the timers that wake it when a deadline it set or resumed runs
+// out, and whichever instance claims an expired deadline first times the
+// player out. A quiz's timers can be paused, which freezes the time left on
+// each of them.
+type questionClock struct {
+	store     *cache.RedisCache
+	onTimeout func(quizCode string, userID uint, d questionDeadline)
+
+	mu     sync.Mutex
+	wakers map[string]map[uint]*time.Timer
+}
+
+// newQuestionClock returns a clock that runs onTimeout for each deadline that
+// runs out before the player answers.
+func newQuestionClock(store *cache.RedisCache, onTimeout func(quizCode string, userID uint, d questionDeadline)) *questionClock {
+	return &questionClock{
+		store:     store,
+		onTimeout: onTimeout,
+		wakers:    make(map[string]map[uint]*time.Timer),
+	}
+}
+
+// start sets the deadline of a question sent to a player at sentAt, replacing
+// any previous one.
+func (c *questionClock) start(quizCode string, userID uint, sessionID uint, question models.Question, index int, sentAt time.Time) questionDeadline {
+	d := questionDeadline{
+		SessionID:  sessionID,
+		QuestionID: question.ID,
+		Index:      index,
+		SentAt:     sentAt,
+		Limit:      time.Duration(question.EffectiveTimeLimit()) * time.Second,
+	}
+	if err := c.store.SetTimer(quizCode, userID, encodeDeadline(d)); err != nil {
+		log.Printf("Error saving the deadline of user %d in %s: %v", userID, quizCode, err)
+	}
+	c.wake(quizCode, userID, d)
+	return d
+}
+
+// wake arms this instance's timer for a deadline, replacing the one it had
+// for the player.
+func (c *questionClock) wake(quizCode string, userID uint, d questionDeadline) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if previous, ok := c.wakers[quizCode][userID]; ok {
+		previous.Stop()
+	}
+	if _, ok := c.wakers[quizCode]; !ok {
+		c.wakers[quizCode] = make(map[uint]*time.Timer)
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(d.ExpiresAt())+answerGracePeriod, func() {
+		c.mu.Lock()
+		if c.wakers[quizCode][userID] == timer {
+			delete(c.wakers[quizCode], userID)
+		}
+		c.mu.Unlock()
+		c.expire(quizCode, userID, d)
+	})
+	c.wakers[quizCode][userID] = timer
+}
+
+// expire times the player out if the deadline armed is still theirs, has run
+// out and is not paused. A deadline another instance moved by pausing and
+// resuming the quiz is waited for again.
+func (c *questionClock) expire(quizCode string, userID uint, armed questionDeadline) {
+	d, value, ok, err := c.load(quizCode, userID)
+	if err != nil {
+		log.Printf("Error loading the deadline of user %d in %s: %v", userID, quizCode, err)
+		return
+	}
+	if !ok || d.QuestionID != armed.QuestionID || d.Index != armed.Index {
+		return
+	}
+	if _, paused, err := c.store.GetPaused(quizCode); err != nil || paused {
+		return
+	}
+	if time.Until(d.ExpiresAt())+answerGracePeriod > 0 {
+		c.wake(quizCode, userID, d)
+		return
+	}
+	claimed, err := c.store.DeleteTimer(quizCode, userID, value)
+	if err != nil {
+		log.Printf("Error claiming the deadline of user %d in %s: %v", userID, quizCode, err)
+		return
+	}
+	if claimed {
+		c.onTimeout(quizCode, userID, d)
+	}
+}
+
+// pause freezes every timer of a quiz. It reports false if they already were.
+func (c *questionClock) pause(quizCode string) bool {
+	paused, err := c.store.SetPaused(quizCode, time.Now())
+	if err != nil {
+		log.Printf("Error pausing the timers of %s: %v", quizCode, err)
+	}
+	return paused
+}
+
+// resume restarts the timers of a paused quiz and returns how long they were
+// paused. The time spent paused does not count towards any player's time on
+// their question.
+func (c *questionClock) resume(quizCode string) (time.Duration, bool) {
+	pausedAt, ok, err := c.store.TakePaused(quizCode)
+	if err != nil {
+		log.Printf("Error resuming the timers of %s: %v", quizCode, err)
+	}
+	if !ok {
+		return 0, false
+	}
+	shift := time.Since(pausedAt)
+
+	values, err := c.store.GetTimers(quizCode)
+	if err != nil {
+		log.Printf("Error loading the deadlines of %s: %v", quizCode, err)
+	}
+	for userID, value := range values {
+		d, err := decodeDeadline(value)
+		if err != nil {
+			continue
+		}
+		d.SentAt = d.SentAt.Add(shift)
+		// A player who answered or timed out meanwhile keeps no deadline.
+		moved, err := c.store.ReplaceTimer(quizCode, userID, value, encodeDeadline(d))
+		if err != nil {
+			log.Printf("Error moving the deadline of user %d in %s: %v", userID, quizCode, err)
+			continue
+		}
+		if moved {
+			c.wake(quizCode, userID, d)
+		}
+	}
+	return shift, true
+}
+
+// peek returns the player's active deadline without stopping it.
+func (c *questionClock) peek(quizCode string, userID uint) (questionDeadline, bool, error) {
+	d, _, ok, err := c.load(quizCode, userID)
+	return d, ok, err
+}
+
+// remaining returns the player's active deadline and the time left on it.
+// The time left of a paused quiz stays as it was when the quiz was paused.
+func (c *questionClock) remaining(quizCode string, userID uint) (questionDeadline, time.Duration, bool, error) {
+	d, _, ok, err := c.load(quizCode, userID)
+	if err != nil || !ok {
+		return questionDeadline{}, 0, false, err
+	}
+	now := time.Now()
+	pausedAt, paused, err := c.store.GetPaused(quizCode)
+	if err != nil {
+		return questionDeadline{}, 0, false, err
+	}
+	if paused {
+		now = pausedAt
+	}
+	left := d.ExpiresAt().Sub(now)
+	if left < 0 {
+		left = 0
+	}
+	return d, left, true, nil
+}
+
+// stop disarms the player's timer if it is still running for questionID. It
+// reports false when the timer already fired or belongs to another question.
+func (c *questionClock) stop(quizCode string, userID uint, questionID uint) (questionDeadline, bool) {
+	d, value, ok, err := c.load(quizCode, userID)
+	if err != nil {
+		log.Printf("Error loading the deadline of user %d in %s: %v", userID, quizCode, err)
+	}
+	if !ok || d.QuestionID != questionID {
+		return questionDeadline{}, false
+	}
+	stopped, err := c.store.DeleteTimer(quizCode, userID, value)
+	if err != nil {
+		log.Printf("Error stopping the deadline of user %d in %s: %v", userID, quizCode, err)
+	}
+	if !stopped {
+		return questionDeadline{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if timer, ok := c.wakers[quizCode][userID]; ok {
+		timer.Stop()
+		delete(c.wakers[quizCode], userID)
+	}
+	return d, true
+}
+
+// clear disarms every timer of a quiz.
+func (c *questionClock) clear(quizCode string) {
+	if err := c.store.ClearTimers(quizCode); err != nil {
+		log.Printf("Error clearing the timers of %s: %v", quizCode, err)
+	}
+	c.forget(quizCode)
+}
+
+// drain disarms every timer of a quiz and returns the deadlines that were
+// still running, keyed by user. Of several instances draining a quiz at once,
+// one gets them all.
+func (c *questionClock) drain(quizCode string) map[uint]questionDeadline {
+	values, err := c.store.TakeTimers(quizCode)
+	if err != nil {
+		log.Printf("Error taking the timers of %s: %v", quizCode, err)
+	}
+	c.forget(quizCode)
+
+	running := make(map[uint]questionDeadline, len(values))
+	for userID, value := range values {
+		if d, err := decodeDeadline(value); err == nil {
+			running[userID] = d
+		}
+	}
+	return running
+}
+
+// forget stops this instance's timers of a quiz.
+func (c *questionClock) forget(quizCode string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, timer := range c.wakers[quizCode] {
+		timer.Stop()
+	}
+	delete(c.wakers, quizCode)
+}
+
+// load reads the player's deadline along with its stored value, which
+// identifies it when it is claimed or moved.
+func (c *questionClock) load(quizCode string, userID uint) (questionDeadline, string, bool, error) {
+	value, ok, err := c.store.GetTimer(quizCode, userID)
+	if err != nil || !ok {
+		return questionDeadline{}, "", false, err
+	}
+	d, err := decodeDeadline(value)
+	if err != nil {
+		return questionDeadline{}, "", false, err
+	}
+	return d, value, true, nil
+}
+
+func encodeDeadline(d questionDeadline) string {
+	data, _ := json.Marshal(d)
+	return string(data)
+}
+
+func decodeDeadline(value string) (questionDeadline, error) {
+	var d questionDeadline
+	err := json.Unmarshal([]byte(value), &d)
+	return d, err
+}
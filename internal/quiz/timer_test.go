@@ -0,0 +1,142 @@
+// backend/internal/quiz/timer_test.go
+package quiz
+
+import (
+	"quiz-system/internal/models"
+	"quiz-system/pkg/cache"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// timeouts collects the deadlines a clock timed out.
+type timeouts chan questionDeadline
+
+func (t timeouts) record(quizCode string, userID uint, d questionDeadline) {
+	t <- d
+}
+
+// expect waits for n timeouts, then makes sure no more follow.
+func (t timeouts) expect(tb testing.TB, n int) {
+	tb.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-t:
+		case <-time.After(2 * time.Second):
+			tb.Fatalf("got %d timeouts, want %d", i, n)
+		}
+	}
+	select {
+	case d := <-t:
+		tb.Fatalf("unexpected timeout of question %d", d.QuestionID)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+// newInstances returns clocks of n server instances sharing one Redis, which
+// all report their timeouts to fired.
+func newInstances(t *testing.T, n int) ([]*questionClock, timeouts) {
+	store := cache.NewRedisCache(miniredis.RunT(t).Addr())
+	fired := make(timeouts, 10)
+	clocks := make([]*questionClock, n)
+	for i := range clocks {
+		clocks[i] = newQuestionClock(store, fired.record)
+	}
+	t.Cleanup(func() {
+		for _, clock := range clocks {
+			clock.forget("ROOM")
+		}
+	})
+	return clocks, fired
+}
+
+// almostDue returns a send time whose deadline, grace period included, runs
+// out after wait.
+func almostDue(question models.Question, wait time.Duration) time.Time {
+	limit := time.Duration(question.EffectiveTimeLimit()) * time.Second
+	return time.Now().Add(wait - limit - answerGracePeriod)
+}
+
+func TestQuestionClockSharedAcrossInstances(t *testing.T) {
+	question := models.Question{ID: 5, TimeLimit: 10}
+
+	t.Run("deadline is seen by every instance", func(t *testing.T) {
+		clocks, _ := newInstances(t, 2)
+		started := clocks[0].start("ROOM", 1, 9, question, 0, time.Now())
+
+		d, ok, err := clocks[1].peek("ROOM", 1)
+		if err != nil || !ok {
+			t.Fatalf("peek() = %v, %v, want the deadline", ok, err)
+		}
+		if d.SessionID != 9 || d.QuestionID != 5 || !d.ExpiresAt().Equal(started.ExpiresAt()) {
+			t.Errorf("peek() = %+v, want %+v", d, started)
+		}
+	})
+
+	t.Run("answer on another instance stops the timer", func(t *testing.T) {
+		clocks, fired := newInstances(t, 2)
+		clocks[0].start("ROOM", 1, 9, question, 0, almostDue(question, 100*time.Millisecond))
+
+		if _, ok := clocks[1].stop("ROOM", 1, question.ID); !ok {
+			t.Fatal("stop() = false, want the running timer stopped")
+		}
+		fired.expect(t, 0)
+	})
+
+	t.Run("timeout fires once", func(t *testing.T) {
+		clocks, fired := newInstances(t, 2)
+		d := clocks[0].start("ROOM", 1, 9, question, 0, almostDue(question, 50*time.Millisecond))
+		// The second instance wakes up for the same deadline.
+		clocks[1].wake("ROOM", 1, d)
+
+		fired.expect(t, 1)
+		if _, ok, _ := clocks[1].peek("ROOM", 1); ok {
+			t.Error("the deadline is still there after timing out")
+		}
+	})
+
+	t.Run("pause on another instance freezes the timer", func(t *testing.T) {
+		clocks, fired := newInstances(t, 3)
+		clocks[0].start("ROOM", 1, 9, question, 0, almostDue(question, 300*time.Millisecond))
+		if !clocks[1].pause("ROOM") {
+			t.Fatal("pause() = false, want the timers paused")
+		}
+		if clocks[2].pause("ROOM") {
+			t.Error("second pause() = true, want false")
+		}
+		_, frozen, _, _ := clocks[2].remaining("ROOM", 1)
+		time.Sleep(400 * time.Millisecond)
+		fired.expect(t, 0)
+
+		_, left, ok, err := clocks[2].remaining("ROOM", 1)
+		if err != nil || !ok || left != frozen {
+			t.Errorf("remaining() while paused = %v, %v, %v, want %v", left, ok, err, frozen)
+		}
+
+		shift, ok := clocks[2].resume("ROOM")
+		if !ok || shift < 600*time.Millisecond {
+			t.Fatalf("resume() = %v, %v, want the time spent paused", shift, ok)
+		}
+		if _, ok := clocks[0].resume("ROOM"); ok {
+			t.Error("second resume() = true, want false")
+		}
+		// The first instance's timer ran out while paused; the resuming
+		// instance times the player out at the moved deadline.
+		fired.expect(t, 1)
+	})
+
+	t.Run("drain takes every deadline once", func(t *testing.T) {
+		clocks, fired := newInstances(t, 2)
+		clocks[0].start("ROOM", 1, 9, question, 0, time.Now())
+		clocks[0].start("ROOM", 2, 9, question, 0, time.Now())
+
+		if got := len(clocks[1].drain("ROOM")); got != 2 {
+			t.Errorf("drain() returned %d deadlines, want 2", got)
+		}
+		if got := len(clocks[0].drain("ROOM")); got != 0 {
+			t.Errorf("second drain() returned %d deadlines, want 0", got)
+		}
+		fired.expect(t, 0)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"quiz-system/internal/models"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -26,6 +27,12 @@ func NewRedisCache(addr string) *RedisCache {
     }
 }
 
+// Client returns the underlying Redis client, for components such as the
+// WebSocket broker that share the connection.
+func (c *RedisCache) Client() *redis.Client {
+    return c.client
+}
+
 func (c *RedisCache) SetQuiz(quiz *models.Quiz) error {
     data, err := json.Marshal(quiz)
     if err != nil {
@@ -48,48 +55,132 @@ func (c *RedisCache) GetQuiz(code string) (*models.Quiz, error) {
     return &quiz, err
 }
 
+// DeleteQuiz drops the cached copy of a quiz so the next read reloads it.
+func (c *RedisCache) DeleteQuiz(code string) error {
+    return c.client.Del(c.ctx, "quiz:"+code).Err()
+}
+
+func leaderboardKey(quizCode string) string {
+    return "leaderboard:" + quizCode
+}
+
+// leaderboardNamesKey holds the usernames of the leaderboard's members, which
+// are stored by user ID.
+func leaderboardNamesKey(quizCode string) string {
+    return "leaderboard:" + quizCode + ":names"
+}
+
+// UpdateLeaderboard replaces the leaderboard with the given totals in one
+// transaction, so readers never see it half rebuilt. It reconciles the live
+// leaderboard with the database.
 func (c *RedisCache) UpdateLeaderboard(quizCode string, entries []models.LeaderboardEntry) error {
-    key := "leaderboard:" + quizCode
-    
-    // Clear existing leaderboard
-    pipe := c.client.Pipeline()
-    pipe.Del(c.ctx, key)
-    
-    // Add new entries
-    for _, entry := range entries {
-        pipe.ZAdd(c.ctx, key, &redis.Z{
-            Score:  float64(entry.TotalScore),
-            Member: entry.Username,
-        })
-    }
-    
-    // Set expiration
-    pipe.Expire(c.ctx, key, 24*time.Hour)
-    
-    _, err := pipe.Exec(c.ctx)
+    key := leaderboardKey(quizCode)
+    namesKey := leaderboardNamesKey(quizCode)
+
+    _, err := c.client.TxPipelined(c.ctx, func(pipe redis.Pipeliner) error {
+        // Clear existing leaderboard
+        pipe.Del(c.ctx, key, namesKey)
+
+        // Add new entries
+        for _, entry := range entries {
+            member := strconv.FormatUint(uint64(entry.UserID), 10)
+            pipe.ZAdd(c.ctx, key, &redis.Z{
+                Score:  float64(entry.TotalScore),
+                Member: member,
+            })
+            pipe.HSet(c.ctx, namesKey, member, entry.Username)
+        }
+
+        // Set expiration
+        pipe.Expire(c.ctx, key, 24*time.Hour)
+        pipe.Expire(c.ctx, namesKey, 24*time.Hour)
+        return nil
+    })
     return err
 }
 
-func (c *RedisCache) SetLeaderboard(quizCode string, scores map[string]int) error {
-    key := "leaderboard:" + quizCode
-    
-    // Clear existing leaderboard
-    if err := c.client.Del(c.ctx, key).Err(); err != nil {
-        return err
+// IncrementScore adds points to a player's total on the live leaderboard,
+// adding the player if they are not on it yet.
+func (c *RedisCache) IncrementScore(quizCode string, userID uint, username string, points int) error {
+    key := leaderboardKey(quizCode)
+    namesKey := leaderboardNamesKey(quizCode)
+    member := strconv.FormatUint(uint64(userID), 10)
+
+    _, err := c.client.TxPipelined(c.ctx, func(pipe redis.Pipeliner) error {
+        pipe.ZIncrBy(c.ctx, key, float64(points), member)
+        pipe.HSet(c.ctx, namesKey, member, username)
+        pipe.Expire(c.ctx, key, 24*time.Hour)
+        pipe.Expire(c.ctx, namesKey, 24*time.Hour)
+        return nil
+    })
+    return err
+}
+
+// RemoveFromLeaderboard drops a player from the session's live leaderboard.
+func (c *RedisCache) RemoveFromLeaderboard(quizCode string, userID uint) error {
+    member := strconv.FormatUint(uint64(userID), 10)
+    _, err := c.client.TxPipelined(c.ctx, func(pipe redis.Pipeliner) error {
+        pipe.ZRem(c.ctx, leaderboardKey(quizCode), member)
+        pipe.HDel(c.ctx, leaderboardNamesKey(quizCode), member)
+        return nil
+    })
+    return err
+}
+
+func leaderboardPendingKey(quizCode string) string {
+    return "leaderboard:" + quizCode + ":pending"
+}
+
+// leaderboardSentKey holds the scores of the session's last leaderboard
+// update, by user ID.
+func leaderboardSentKey(quizCode string) string {
+    return "leaderboard:" + quizCode + ":sent"
+}
+
+// ClaimLeaderboardUpdate reports whether the caller is the one to send the
+// session's next leaderboard update. Once claimed, it cannot be claimed again
+// for the interval.
+func (c *RedisCache) ClaimLeaderboardUpdate(quizCode string, interval time.Duration) (bool, error) {
+    return c.client.SetNX(c.ctx, leaderboardPendingKey(quizCode), 1, interval).Result()
+}
+
+// SwapSentScores records the scores of a leaderboard update as the last ones
+// sent and returns those of the previous update.
+func (c *RedisCache) SwapSentScores(quizCode string, scores map[uint]int) (map[uint]int, error) {
+    key := leaderboardSentKey(quizCode)
+    var previous *redis.StringStringMapCmd
+    _, err := c.client.TxPipelined(c.ctx, func(pipe redis.Pipeliner) error {
+        previous = pipe.HGetAll(c.ctx, key)
+        pipe.Del(c.ctx, key)
+        if len(scores) > 0 {
+            values := make(map[string]interface{}, len(scores))
+            for userID, score := range scores {
+                values[userField(userID)] = score
+            }
+            pipe.HSet(c.ctx, key, values)
+            pipe.Expire(c.ctx, key, 24*time.Hour)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
     }
-    
-    // Add all scores in a pipeline
-    pipe := c.client.Pipeline()
-    for username, score := range scores {
-        pipe.ZAdd(c.ctx, key, &redis.Z{
-            Score:  float64(score),
-            Member: username,
-        })
+
+    sent := make(map[uint]int, len(previous.Val()))
+    for userID, value := range byUser(previous.Val()) {
+        score, err := strconv.Atoi(value)
+        if err != nil {
+            continue
+        }
+        sent[userID] = score
     }
-    pipe.Expire(c.ctx, key, 24*time.Hour)
-    
-    _, err := pipe.Exec(c.ctx)
-    return err
+    return sent, nil
+}
+
+// ForgetLeaderboardUpdates drops the scores last sent for the session and any
+// claim on its next update.
+func (c *RedisCache) ForgetLeaderboardUpdates(quizCode string) error {
+    return c.client.Del(c.ctx, leaderboardSentKey(quizCode), leaderboardPendingKey(quizCode)).Err()
 }
 
 func (c *RedisCache) RemoveUserQuizData(quizCode string, userID uint) error {
@@ -97,22 +188,38 @@ func (c *RedisCache) RemoveUserQuizData(quizCode string, userID uint) error {
     return c.client.Del(context.Background(), key).Err()
 }
 
+// GetLeaderboard returns every player on the leaderboard, highest score first.
 func (c *RedisCache) GetLeaderboard(quizCode string) ([]models.LeaderboardEntry, error) {
-    key := "leaderboard:" + quizCode
+    key := leaderboardKey(quizCode)
     
     // Get all entries sorted by score (descending)
     results, err := c.client.ZRevRangeWithScores(c.ctx, key, 0, -1).Result()
     if err != nil {
         return nil, err
     }
+    if len(results) == 0 {
+        return []models.LeaderboardEntry{}, nil
+    }
+
+    members := make([]string, len(results))
+    for i, z := range results {
+        members[i] = z.Member.(string)
+    }
+    names, err := c.client.HMGet(c.ctx, leaderboardNamesKey(quizCode), members...).Result()
+    if err != nil {
+        return nil, err
+    }
     
     entries := make([]models.LeaderboardEntry, len(results))
     for i, z := range results {
+        userID, _ := strconv.ParseUint(members[i], 10, 64)
+        username, _ := names[i].(string)
         entries[i] = models.LeaderboardEntry{
-            Username:   z.Member.(string),
+            UserID:     uint(userID),
+            Username:   username,
             TotalScore: int(z.Score),
         }
     }
     
     return entries, nil
-}
\ No newline at end of file
+}
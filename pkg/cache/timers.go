@@ -0,0 +1,165 @@
+// backend/pkg/cache/timers.go
+package cache
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// The question timers of a session are kept here rather than in the memory of
+// one server, so every instance sees the same deadlines. Each timer is an
+// opaque value stored per player; the compare-and-swap operations below let
+// several instances race on the same timer with exactly one of them winning.
+
+// timerTTL bounds how long the timers of an abandoned session are kept.
+const timerTTL = 24 * time.Hour
+
+func timersKey(quizCode string) string {
+	return "timers:" + quizCode
+}
+
+// pausedKey holds the moment the session's timers were paused, in Unix
+// milliseconds, while they are.
+func pausedKey(quizCode string) string {
+	return "timers:" + quizCode + ":paused"
+}
+
+// deleteIfEqual deletes a hash field only if it still holds the given value.
+var deleteIfEqual = redis.NewScript(`
+if redis.call("HGET", KEYS[1], ARGV[1]) == ARGV[2] then
+	return redis.call("HDEL", KEYS[1], ARGV[1])
+end
+return 0
+`)
+
+// replaceIfEqual sets a hash field only if it still holds the given value.
+var replaceIfEqual = redis.NewScript(`
+if redis.call("HGET", KEYS[1], ARGV[1]) == ARGV[2] then
+	redis.call("HSET", KEYS[1], ARGV[1], ARGV[3])
+	return 1
+end
+return 0
+`)
+
+// SetTimer stores the player's timer, replacing any previous one.
+func (c *RedisCache) SetTimer(quizCode string, userID uint, value string) error {
+	key := timersKey(quizCode)
+	_, err := c.client.TxPipelined(c.ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(c.ctx, key, userField(userID), value)
+		pipe.Expire(c.ctx, key, timerTTL)
+		return nil
+	})
+	return err
+}
+
+// GetTimer returns the player's timer; ok is false when they have none.
+func (c *RedisCache) GetTimer(quizCode string, userID uint) (value string, ok bool, err error) {
+	value, err = c.client.HGet(c.ctx, timersKey(quizCode), userField(userID)).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	return value, err == nil, err
+}
+
+// GetTimers returns every timer of the session, keyed by user.
+func (c *RedisCache) GetTimers(quizCode string) (map[uint]string, error) {
+	fields, err := c.client.HGetAll(c.ctx, timersKey(quizCode)).Result()
+	if err != nil {
+		return nil, err
+	}
+	return byUser(fields), nil
+}
+
+// DeleteTimer deletes the player's timer if it is still the given value, and
+// reports whether it did.
+func (c *RedisCache) DeleteTimer(quizCode string, userID uint, value string) (bool, error) {
+	deleted, err := deleteIfEqual.Run(c.ctx, c.client, []string{timersKey(quizCode)}, userField(userID), value).Int()
+	return deleted == 1, err
+}
+
+// ReplaceTimer swaps the player's timer for next if it is still the given
+// value, and reports whether it did.
+func (c *RedisCache) ReplaceTimer(quizCode string, userID uint, value, next string) (bool, error) {
+	replaced, err := replaceIfEqual.Run(c.ctx, c.client, []string{timersKey(quizCode)}, userField(userID), value, next).Int()
+	return replaced == 1, err
+}
+
+// TakeTimers deletes every timer of the session, and its pause, and returns
+// the timers. Of several instances taking them at once, one gets them all.
+func (c *RedisCache) TakeTimers(quizCode string) (map[uint]string, error) {
+	var fields *redis.StringStringMapCmd
+	_, err := c.client.TxPipelined(c.ctx, func(pipe redis.Pipeliner) error {
+		fields = pipe.HGetAll(c.ctx, timersKey(quizCode))
+		pipe.Del(c.ctx, timersKey(quizCode), pausedKey(quizCode))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return byUser(fields.Val()), nil
+}
+
+// SetPaused records that the session's timers were paused at the given time.
+// It reports false, leaving the first time, if they already were.
+func (c *RedisCache) SetPaused(quizCode string, at time.Time) (bool, error) {
+	return c.client.SetNX(c.ctx, pausedKey(quizCode), at.UnixMilli(), timerTTL).Result()
+}
+
+// GetPaused returns when the session's timers were paused; ok is false when
+// they are running.
+func (c *RedisCache) GetPaused(quizCode string) (at time.Time, ok bool, err error) {
+	millis, err := c.client.Get(c.ctx, pausedKey(quizCode)).Int64()
+	if err == redis.Nil {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return time.UnixMilli(millis), true, nil
+}
+
+// TakePaused ends the pause of the session's timers and returns when it
+// began. Of several instances ending it at once, only one gets ok.
+func (c *RedisCache) TakePaused(quizCode string) (at time.Time, ok bool, err error) {
+	var millis *redis.StringCmd
+	_, err = c.client.TxPipelined(c.ctx, func(pipe redis.Pipeliner) error {
+		millis = pipe.Get(c.ctx, pausedKey(quizCode))
+		pipe.Del(c.ctx, pausedKey(quizCode))
+		return nil
+	})
+	if err == redis.Nil {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	ms, err := millis.Int64()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return time.UnixMilli(ms), true, nil
+}
+
+// ClearTimers deletes every timer of the session and its pause.
+func (c *RedisCache) ClearTimers(quizCode string) error {
+	return c.client.Del(c.ctx, timersKey(quizCode), pausedKey(quizCode)).Err()
+}
+
+func userField(userID uint) string {
+	return strconv.FormatUint(uint64(userID), 10)
+}
+
+// byUser keys hash fields named by user ID by that ID, skipping any other.
+func byUser(fields map[string]string) map[uint]string {
+	values := make(map[uint]string, len(fields))
+	for field, value := range fields {
+		userID, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			continue
+		}
+		values[uint(userID)] = value
+	}
+	return values
+}
@@ -25,7 +25,8 @@ func NewPostgresDB(config *Config) (*gorm.DB, error) {
         config.Port,
     )
 
-    db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+    // TranslateError maps unique violations to gorm.ErrDuplicatedKey.
+    db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
     if err != nil {
         return nil, err
     }
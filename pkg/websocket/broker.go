@@ -0,0 +1,212 @@
+package websocket
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Broker carries room messages between server instances, so players of one
+// quiz can be connected to different instances. Every instance following a
+// room receives its messages in the same order, numbered by the broker.
+type Broker interface {
+	// Publish sends a room message to every instance, the sender included.
+	Publish(quizCode string, payload []byte) error
+	// Subscribe calls deliver with each message of the room and its sequence
+	// number, in order, until the returned cancel function is called.
+	Subscribe(quizCode string, deliver func(seq uint64, payload []byte)) (cancel func(), err error)
+}
+
+// Presence tracks who is connected to each room across all instances. A
+// user connected more than once counts once until the last connection leaves.
+type Presence interface {
+	Join(quizCode string, user UserInfo, isHost bool) error
+	Leave(quizCode string, userID uint, isHost bool) error
+	Members(quizCode string) ([]Member, error)
+	// RoomOf returns the room a user last joined, or "" if none. It is kept
+	// after they leave, until Forget, so messages to them can be replayed.
+	RoomOf(userID uint, isHost bool) (string, error)
+	Forget(quizCode string, userID uint, isHost bool) error
+}
+
+// Member is a user connected to a room.
+type Member struct {
+	UserInfo
+	IsHost bool `json:"is_host"`
+}
+
+// envelope is a room message on its way through the broker, before it is
+// given its sequence number. A nil copy is not sent to that audience; copies
+// in UserData go to particular users instead of their audience's copy.
+type envelope struct {
+	Type       string                   `json:"type"`
+	HostData   json.RawMessage          `json:"host_data,omitempty"`
+	PlayerData json.RawMessage          `json:"player_data,omitempty"`
+	UserData   map[uint]json.RawMessage `json:"user_data,omitempty"`
+	// SpectatorData is the copy for spectator displays.
+	SpectatorData json.RawMessage `json:"spectator_data,omitempty"`
+	// Disconnect asks every instance to close the user's connections once
+	// the message is sent.
+	Disconnect *disconnect `json:"disconnect,omitempty"`
+}
+
+// disconnect describes a player the host removed from a room.
+type disconnect struct {
+	UserID    uint   `json:"user_id"`
+	CloseCode int    `json:"close_code"`
+	Reason    string `json:"reason"`
+}
+
+// subscribers keeps the delivery functions following each room. Both broker
+// implementations use it.
+type subscribers struct {
+	mu     sync.Mutex
+	nextID int
+	rooms  map[string]map[int]func(seq uint64, payload []byte)
+}
+
+func newSubscribers() *subscribers {
+	return &subscribers{rooms: make(map[string]map[int]func(seq uint64, payload []byte))}
+}
+
+// add registers deliver for a room and reports whether it is the room's first
+// subscriber.
+func (s *subscribers) add(quizCode string, deliver func(seq uint64, payload []byte)) (id int, first bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	if s.rooms[quizCode] == nil {
+		s.rooms[quizCode] = make(map[int]func(seq uint64, payload []byte))
+		first = true
+	}
+	s.rooms[quizCode][s.nextID] = deliver
+	return s.nextID, first
+}
+
+// remove drops a subscriber and reports whether the room has none left.
+func (s *subscribers) remove(quizCode string, id int) (last bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.rooms[quizCode], id)
+	if len(s.rooms[quizCode]) == 0 {
+		delete(s.rooms, quizCode)
+		return true
+	}
+	return false
+}
+
+func (s *subscribers) deliver(quizCode string, seq uint64, payload []byte) {
+	s.mu.Lock()
+	targets := make([]func(seq uint64, payload []byte), 0, len(s.rooms[quizCode]))
+	for _, deliver := range s.rooms[quizCode] {
+		targets = append(targets, deliver)
+	}
+	s.mu.Unlock()
+
+	for _, deliver := range targets {
+		deliver(seq, payload)
+	}
+}
+
+// localBroker delivers room messages within a single instance.
+type localBroker struct {
+	mu   sync.Mutex
+	seqs map[string]uint64
+	subs *subscribers
+}
+
+// NewLocalBroker returns a broker for running a single server instance.
+func NewLocalBroker() Broker {
+	return &localBroker{seqs: make(map[string]uint64), subs: newSubscribers()}
+}
+
+func (b *localBroker) Publish(quizCode string, payload []byte) error {
+	// Delivering under the lock keeps messages in sequence order.
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seqs[quizCode]++
+	b.subs.deliver(quizCode, b.seqs[quizCode], payload)
+	return nil
+}
+
+func (b *localBroker) Subscribe(quizCode string, deliver func(seq uint64, payload []byte)) (func(), error) {
+	id, _ := b.subs.add(quizCode, deliver)
+	return func() { b.subs.remove(quizCode, id) }, nil
+}
+
+// localPresence tracks the connections of a single instance.
+type localPresence struct {
+	mu    sync.Mutex
+	rooms map[string]map[Member]int
+	last  map[Member]string
+}
+
+// NewLocalPresence returns presence tracking for a single server instance.
+func NewLocalPresence() Presence {
+	return &localPresence{
+		rooms: make(map[string]map[Member]int),
+		last:  make(map[Member]string),
+	}
+}
+
+func (p *localPresence) Join(quizCode string, user UserInfo, isHost bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.rooms[quizCode] == nil {
+		p.rooms[quizCode] = make(map[Member]int)
+	}
+	p.rooms[quizCode][Member{UserInfo: user, IsHost: isHost}]++
+	p.last[Member{UserInfo: UserInfo{UserID: user.UserID}, IsHost: isHost}] = quizCode
+	return nil
+}
+
+func (p *localPresence) Leave(quizCode string, userID uint, isHost bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for member, count := range p.rooms[quizCode] {
+		if member.UserID != userID || member.IsHost != isHost {
+			continue
+		}
+		if count <= 1 {
+			delete(p.rooms[quizCode], member)
+		} else {
+			p.rooms[quizCode][member] = count - 1
+		}
+	}
+	if len(p.rooms[quizCode]) == 0 {
+		delete(p.rooms, quizCode)
+	}
+	return nil
+}
+
+func (p *localPresence) Members(quizCode string) ([]Member, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	members := make([]Member, 0, len(p.rooms[quizCode]))
+	for member := range p.rooms[quizCode] {
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+func (p *localPresence) RoomOf(userID uint, isHost bool) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.last[Member{UserInfo: UserInfo{UserID: userID}, IsHost: isHost}], nil
+}
+
+func (p *localPresence) Forget(quizCode string, userID uint, isHost bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := Member{UserInfo: UserInfo{UserID: userID}, IsHost: isHost}
+	if p.last[key] == quizCode {
+		delete(p.last, key)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package websocket
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// received collects what a broker delivered to a subscriber.
+type received struct {
+	mu       sync.Mutex
+	messages []string // "seq:payload"
+}
+
+func (r *received) deliver(seq uint64, payload []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, fmt.Sprintf("%d:%s", seq, payload))
+}
+
+// wait returns the first n messages once they have arrived.
+func (r *received) wait(t *testing.T, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		r.mu.Lock()
+		got := append([]string(nil), r.messages...)
+		r.mu.Unlock()
+		if len(got) >= n {
+			return got
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d messages %v, want %d", len(got), got, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLocalBrokerNumbersEachRoom(t *testing.T) {
+	broker := NewLocalBroker()
+	var first, second, other received
+	cancel, _ := broker.Subscribe("ROOM", first.deliver)
+	broker.Subscribe("ROOM", second.deliver)
+	broker.Subscribe("OTHER", other.deliver)
+
+	broker.Publish("ROOM", []byte("a"))
+	broker.Publish("OTHER", []byte("x"))
+	broker.Publish("ROOM", []byte("b"))
+	cancel()
+	broker.Publish("ROOM", []byte("c"))
+
+	if got, want := first.wait(t, 2), []string{"1:a", "2:b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first subscriber got %v, want %v", got, want)
+	}
+	if got, want := second.wait(t, 3), []string{"1:a", "2:b", "3:c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("second subscriber got %v, want %v", got, want)
+	}
+	if got, want := other.wait(t, 1), []string{"1:x"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("other room got %v, want %v", got, want)
+	}
+}
+
+func TestRedisBrokerSharesSequenceAcrossInstances(t *testing.T) {
+	server := miniredis.RunT(t)
+	newInstance := func() (*RedisBroker, *received) {
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+		var got received
+		broker := NewRedisBroker(client)
+		if _, err := broker.Subscribe("ROOM", got.deliver); err != nil {
+			t.Fatal(err)
+		}
+		return broker, &got
+	}
+	first, firstGot := newInstance()
+	second, secondGot := newInstance()
+
+	// Wait for both subscriptions to reach the server.
+	deadline := time.Now().Add(2 * time.Second)
+	for server.PubSubNumSub(roomChannel("ROOM"))[roomChannel("ROOM")] < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("the brokers did not subscribe")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	var publishers sync.WaitGroup
+	for _, broker := range []*RedisBroker{first, second} {
+		publishers.Add(1)
+		go func(broker *RedisBroker) {
+			defer publishers.Done()
+			for i := 0; i < 10; i++ {
+				if err := broker.Publish("ROOM", []byte("m")); err != nil {
+					t.Error(err)
+				}
+			}
+		}(broker)
+	}
+	publishers.Wait()
+
+	want := make([]string, 20)
+	for i := range want {
+		want[i] = fmt.Sprintf("%d:m", i+1)
+	}
+	for name, got := range map[string]*received{"first": firstGot, "second": secondGot} {
+		if messages := got.wait(t, 20); !reflect.DeepEqual(messages, want) {
+			t.Errorf("%s instance got %v, want %v", name, messages, want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// eventLogSize is how many recent messages each room keeps for clients that
+// reconnect with last_seq.
+const eventLogSize = 256
+
+// CloseLagging closes a client whose send buffer filled up. It can reconnect
+// with last_seq to catch up.
+const CloseLagging = 4008
+
+// loggedEvent is one sequenced message of a room, encoded for each audience.
+// users holds the copies addressed to particular users; the host and player
+// copies go to every other host and player client, and spectators get the
+// spectator copy. A nil copy is not sent.
+type loggedEvent struct {
+	seq       uint64
+	host      []byte
+	player    []byte
+	spectator []byte
+	users     map[uint][]byte
+}
+
+// messageFor returns the copy of the event a client receives, or nil.
+func (e *loggedEvent) messageFor(c *Client) []byte {
+	if c.spectator {
+		return e.spectator
+	}
+	if c.user != nil {
+		if message, ok := e.users[c.user.UserID]; ok {
+			return message
+		}
+	}
+	if c.isHost {
+		return e.host
+	}
+	return e.player
+}
+
+// eventLog numbers the messages of a room and keeps the latest of them. It
+// belongs to the room goroutine.
+type eventLog struct {
+	next   uint64        // sequence number of the next message
+	first  uint64        // oldest sequence number still in events
+	events []loggedEvent // ring buffer indexed by seq % eventLogSize
+}
+
+func newEventLog() *eventLog {
+	return &eventLog{next: 1, first: 1}
+}
+
+// add records an event. An event that does not follow the last one, as when
+// the instance starts following a room mid-game, starts the log afresh.
+func (l *eventLog) add(e loggedEvent) {
+	if l.events == nil {
+		l.events = make([]loggedEvent, eventLogSize)
+	}
+	if e.seq != l.next {
+		l.first = e.seq
+	}
+	l.events[e.seq%eventLogSize] = e
+	l.next = e.seq + 1
+	if l.next-l.first > eventLogSize {
+		l.first = l.next - eventLogSize
+	}
+}
+
+// covers reports whether every event after lastSeq is still in the log.
+func (l *eventLog) covers(lastSeq uint64) bool {
+	return lastSeq+1 >= l.first && lastSeq < l.next
+}
+
+// after returns the events after lastSeq in order. Callers have checked that
+// the log covers lastSeq.
+func (l *eventLog) after(lastSeq uint64) []loggedEvent {
+	events := make([]loggedEvent, 0, l.next-lastSeq-1)
+	for seq := lastSeq + 1; seq < l.next; seq++ {
+		events = append(events, l.events[seq%eventLogSize])
+	}
+	return events
+}
+
+// encode marshals a message with its sequence number, or returns nil.
+func encode(seq uint64, messageType string, data interface{}) []byte {
+	messageBytes, err := json.Marshal(Message{Type: messageType, Seq: seq, Data: data})
+	if err != nil {
+		log.Printf("Error marshaling %s message: %v", messageType, err)
+		return nil
+	}
+	return messageBytes
+}
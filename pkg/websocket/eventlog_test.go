@@ -0,0 +1,73 @@
+package websocket
+
+import "testing"
+
+// logWith returns an event log that recorded the events seqs, in order.
+func logWith(seqs ...uint64) *eventLog {
+	l := newEventLog()
+	for _, seq := range seqs {
+		l.add(loggedEvent{seq: seq})
+	}
+	return l
+}
+
+// seqRange returns the sequence numbers from first to last inclusive.
+func seqRange(first, last uint64) []uint64 {
+	var seqs []uint64
+	for seq := first; seq <= last; seq++ {
+		seqs = append(seqs, seq)
+	}
+	return seqs
+}
+
+func TestEventLogReplay(t *testing.T) {
+	tests := []struct {
+		name    string
+		log     *eventLog
+		lastSeq uint64
+		covered bool
+		want    []uint64 // the events after lastSeq, when covered
+	}{
+		{"empty log from the start", logWith(), 0, true, nil},
+		{"empty log, client ahead", logWith(), 1, false, nil},
+		{"from the start", logWith(1, 2, 3), 0, true, []uint64{1, 2, 3}},
+		{"midway", logWith(1, 2, 3), 1, true, []uint64{2, 3}},
+		{"up to date", logWith(1, 2, 3), 3, true, nil},
+		{"client ahead of the log", logWith(1, 2, 3), 4, false, nil},
+
+		{"full log from the start", logWith(seqRange(1, eventLogSize)...), 0, true, seqRange(1, eventLogSize)},
+		{"one past full drops the first event", logWith(seqRange(1, eventLogSize+1)...), 0, false, nil},
+		{"one past full from the oldest kept", logWith(seqRange(1, eventLogSize+1)...), 1, true, seqRange(2, eventLogSize+1)},
+
+		{"wrapped, oldest kept", logWith(seqRange(1, 300)...), 300 - eventLogSize, true, seqRange(300-eventLogSize+1, 300)},
+		{"wrapped, just too old", logWith(seqRange(1, 300)...), 300 - eventLogSize - 1, false, nil},
+		{"wrapped, from the start", logWith(seqRange(1, 300)...), 0, false, nil},
+		{"wrapped, last few", logWith(seqRange(1, 300)...), 297, true, []uint64{298, 299, 300}},
+		{"wrapped twice", logWith(seqRange(1, 3*eventLogSize+5)...), 3*eventLogSize + 2, true, seqRange(3*eventLogSize+3, 3*eventLogSize+5)},
+
+		{"joined mid-game", logWith(100, 101), 99, true, []uint64{100, 101}},
+		{"joined mid-game, from the start", logWith(100, 101), 0, false, nil},
+		{"gap restarts the log", logWith(1, 2, 10, 11), 9, true, []uint64{10, 11}},
+		{"gap hides the events before it", logWith(1, 2, 10, 11), 1, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.log.covers(tt.lastSeq); got != tt.covered {
+				t.Fatalf("covers(%d) = %v, want %v", tt.lastSeq, got, tt.covered)
+			}
+			if !tt.covered {
+				return
+			}
+			events := tt.log.after(tt.lastSeq)
+			if len(events) != len(tt.want) {
+				t.Fatalf("after(%d) returned %d events, want %d", tt.lastSeq, len(events), len(tt.want))
+			}
+			for i, event := range events {
+				if event.seq != tt.want[i] {
+					t.Errorf("after(%d)[%d].seq = %d, want %d", tt.lastSeq, i, event.seq, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -2,9 +2,12 @@ package websocket
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"quiz-system/internal/models"
+	"strconv"
 	"sync"
 	"time"
 
@@ -13,8 +16,12 @@ import (
 )
 
 // Message represents the standard message format exchanged over WebSocket.
+// Messages a room sends carry Seq, which increases by one with each message
+// of the room; a client reconnecting with the last Seq it saw is sent what it
+// missed.
 type Message struct {
 	Type string      `json:"type"`
+	Seq  uint64      `json:"seq,omitempty"`
 	Data interface{} `json:"data"`
 }
 
@@ -25,47 +32,85 @@ const (
 	maxMessageSize = 512
 )
 
-// upgrader configures the WebSocket connection upgrade.
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	// Allow all origins. Adjust this in production!
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
+// Close codes of connections the host removed from a session.
+const (
+	CloseKicked = 4001
+	CloseBanned = 4003
+)
+
+// maxCloseReason is the longest reason a close frame can carry.
+const maxCloseReason = 123
+
+// reconnectGracePeriod is how long a player whose connection dropped stays in
+// the session, keeping their progress, before they are removed from it.
+const reconnectGracePeriod = 30 * time.Second
+
+// newUpgrader configures the WebSocket connection upgrade.
+func newUpgrader() websocket.Upgrader {
+	return websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		// Allow all origins. Adjust this in production!
+		CheckOrigin: func(r *http.Request) bool {
+			return true
+		},
+	}
 }
 
+// Role is how a connection takes part in its room. Spectators are read-only
+// displays: they see what players see, minus anything personal, and are not
+// counted as participants.
+type Role string
+
+const (
+	RoleHost      Role = "host"
+	RolePlayer    Role = "player"
+	RoleSpectator Role = "spectator"
+)
+
 type UserInfo struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
 	Email    string `json:"email"`
 }
 
-// Hub struct in hub.go
+// Hub routes connections, player messages and timer ticks to the goroutine
+// of the room they belong to; the rooms hold all per-room state.
 type Hub struct {
-	clients       map[*Client]bool
-	quizRooms     map[string]map[*Client]bool
-	participants  map[string]int
-	register      chan *Client
-	unregister    chan *Client
-	mu            sync.RWMutex
-	quizService   QuizServiceInterface // Existing interface
-	clientsByUser map[uint]*Client     // For non-host participants
-	hosts         map[uint]*Client     // NEW: for hosts (quiz creators)
+	rooms        map[string]*room
+	mu           sync.Mutex           // guards rooms
+	quizService  QuizServiceInterface // Existing interface
+	authenticate Authenticator
+	// authenticateScreen checks the screen tokens of spectator displays.
+	authenticateScreen ScreenAuthenticator
+	errorCode          ErrorCoder
+	upgrader           websocket.Upgrader
+	// broker carries room messages between instances; presence tracks who
+	// is connected to each room on any instance.
+	broker   Broker
+	presence Presence
 }
 
 func NewHub() *Hub {
 	return &Hub{
-		clients:       make(map[*Client]bool),
-		quizRooms:     make(map[string]map[*Client]bool),
-		participants:  make(map[string]int),
-		register:      make(chan *Client),
-		unregister:    make(chan *Client),
-		clientsByUser: make(map[uint]*Client),
-		hosts:         make(map[uint]*Client),
+		rooms:    make(map[string]*room),
+		broker:   NewLocalBroker(),
+		presence: NewLocalPresence(),
+		upgrader: newUpgrader(),
 	}
 }
 
+// SetBroker replaces the in-process broker, for example with a RedisBroker
+// when several instances serve the same rooms. Call it before serving.
+func (h *Hub) SetBroker(broker Broker) {
+	h.broker = broker
+}
+
+// SetPresence replaces the in-process presence tracking. Call it before serving.
+func (h *Hub) SetPresence(presence Presence) {
+	h.presence = presence
+}
+
 // Add method to register services
 // func (h *Hub) RegisterService(name string, service interface{}) {
 //     h.services[name] = service
@@ -75,227 +120,282 @@ func (h *Hub) SetQuizService(service QuizServiceInterface) {
 	h.quizService = service
 }
 
+// Authenticator resolves the user behind a WebSocket handshake request.
+type Authenticator func(r *http.Request) (userID uint, username string, err error)
+
+// SetSubprotocols lists the subprotocols the server accepts in the handshake,
+// such as the one browsers carry their token in. Call it before serving.
+func (h *Hub) SetSubprotocols(protocols ...string) {
+	h.upgrader.Subprotocols = protocols
+}
+
+// SetAuthenticator configures how handshake requests are authenticated.
+// Connections are refused until one is set.
+func (h *Hub) SetAuthenticator(authenticate Authenticator) {
+	h.authenticate = authenticate
+}
+
+// post queues an operation on the goroutine of a room, starting the room if
+// it is not running here.
+func (h *Hub) post(quizCode string, op func(*room)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r, ok := h.rooms[quizCode]
+	if !ok {
+		r = newRoom(h, quizCode)
+		h.rooms[quizCode] = r
+		go r.run()
+	}
+	r.post(op)
+}
+
+// closeRoom stops routing to an idle room. It reports false if something was
+// queued on the room in the meantime, in which case the room keeps running.
+func (h *Hub) closeRoom(r *room) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.ops) > 0 {
+		return false
+	}
+	r.closed = true
+	if h.rooms[r.quizCode] == r {
+		delete(h.rooms, r.quizCode)
+	}
+	return true
+}
+
+// Dispatch runs fn on the goroutine of a room, in order with the room's
+// joins, leaves and player messages. The quiz service hands its timer ticks
+// to the room this way.
+func (h *Hub) Dispatch(quizCode string, fn func()) {
+	h.post(quizCode, func(*room) { fn() })
+}
+
+// ScreenAuthenticator checks the screen token of a spectator's handshake
+// request and returns the join code of the session it may watch. ok is false
+// when the request carries no screen token, in which case it is
+// authenticated as a user.
+type ScreenAuthenticator func(r *http.Request) (joinCode string, ok bool, err error)
+
+// SetScreenAuthenticator configures how spectator displays are authenticated.
+// Without one every connection is authenticated as a user.
+func (h *Hub) SetScreenAuthenticator(authenticate ScreenAuthenticator) {
+	h.authenticateScreen = authenticate
+}
+
+// ErrorCoder returns the error code sent to a client for an error the quiz
+// service returned.
+type ErrorCoder func(err error) ErrorCode
+
+// SetErrorCoder configures the codes of service errors. Without one they are
+// all reported as internal errors.
+func (h *Hub) SetErrorCoder(errorCode ErrorCoder) {
+	h.errorCode = errorCode
+}
+
 type QuizServiceInterface interface {
-    HandleNextQuestion(quizCode string, currentIndex int) error
-    GetQuizByCode(quizCode string) (*models.Quiz, error)
+    HandleNextQuestion(quizCode string, userID uint, currentIndex int) error
+    ResolveSession(code string) (*models.QuizSession, error)
     RemoveParticipant(quizCode string, userID uint) error
-    JoinQuiz(quizCode string, userID uint) error
+    JoinQuiz(quizCode string, userID uint) (*models.QuizSession, error)
     HandleNextQuestionForUser(userID uint, quizCode string, nextIndex int) error
     GetLeaderboard(quizCode string) ([]models.LeaderboardEntry, error)
-    StartQuiz(quizCode string, userID uint) error
-}
-
-func (h *Hub) checkIfHost(quizCode string, userID uint) (bool, error) {
-	// Use your quiz service to retrieve quiz details.
-	quiz, err := h.quizService.GetQuizByCode(quizCode)
-	if err != nil {
-		return false, err
-	}
-	return quiz.CreatorID == userID, nil
+    ResumePlayer(quizCode string, userID uint) error
+    StartQuiz(quizCode string, userID uint) (*models.QuizSession, error)
+    RevealResults(quizCode string, userID uint) error
+    PauseSession(quizCode string, userID uint) (*models.QuizSession, error)
+    ResumeSession(quizCode string, userID uint) (*models.QuizSession, error)
+    SkipQuestion(quizCode string, userID uint) (*models.QuizSession, error)
+    EndSession(quizCode string, userID uint) (*models.QuizSession, error)
+    KickParticipant(quizCode string, hostID, userID uint, reason string) error
+    BanParticipant(quizCode string, hostID, userID uint, reason string) error
+    IsBanned(sessionID, userID uint) (bool, error)
+    ParticipantIDs(quizCode string) ([]uint, error)
 }
 
 type Client struct {
-	hub      *Hub
-	conn     *websocket.Conn
-	send     chan []byte
-	quizCode string
-	user     *UserInfo
-	isHost   bool          // NEW: indicates if this client is the host
-	done     chan struct{} // New done channel to signal shutdown
+	hub       *Hub
+	conn      *websocket.Conn
+	send      chan []byte
+	quizCode  string // join code of the session the client plays in
+	user      *UserInfo
+	isHost    bool  // NEW: indicates if this client is the host
+	spectator bool  // a read-only display; user is a placeholder
+	room      *room // set once the client has joined its room
+	protocol  int   // protocol version agreed at the handshake
+	// closeMessage is the close frame sent once send is closed; it carries the
+	// reason when the host removed the client.
+	closeMessage []byte
 }
 
+// BroadcastToQuiz sends an encoded Message to every client in the room. The
+// message is given the room's next sequence number.
 func (h *Hub) BroadcastToQuiz(quizCode string, message []byte) {
-	// Use RLock() for reading only
-	h.mu.RLock()
-	clients := h.quizRooms[quizCode]
-	h.mu.RUnlock() // Release the lock immediately after reading
-
-	log.Printf("BroadcastToQuiz: Starting broadcast to quiz %s", quizCode)
-
-	if len(clients) == 0 {
-		log.Printf("No clients found for quiz room: %s", quizCode)
+	var msg Message
+	if err := json.Unmarshal(message, &msg); err != nil {
+		log.Printf("Error decoding message for quiz %s: %v", quizCode, err)
 		return
 	}
+	h.broadcast(quizCode, msg.Type, msg.Data, msg.Data)
+}
 
-	log.Printf("Found %d clients in room %s", len(clients), quizCode)
-
-	// Create a copy of clients to avoid concurrent map access
-	clientsCopy := make([]*Client, 0, len(clients))
-	for client := range clients {
-		if client != nil {
-			clientsCopy = append(clientsCopy, client)
-		}
+// broadcast sends hostData to the host clients of a room and playerData to
+// every other client.
+func (h *Hub) broadcast(quizCode string, messageType string, hostData, playerData interface{}) {
+	hostBytes, err := json.Marshal(hostData)
+	if err != nil {
+		log.Printf("Error marshaling host message: %v", err)
+		return
 	}
-
-	// Send messages via each client's send channel
-	for _, client := range clientsCopy {
-		func(c *Client) {
-			defer func() {
-				if r := recover(); r != nil {
-					log.Printf("Recovered from panic while sending message to client %p: %v", c, r)
-					h.unregister <- c
-				}
-			}()
-
-			// Instead of writing directly to the connection, send the message through the channel.
-			select {
-			case c.send <- message:
-				log.Printf("Queued message for client %p", c)
-			default:
-				log.Printf("Send channel full for client %p; unregistering client", c)
-				h.unregister <- c
-			}
-		}(client)
+	playerBytes, err := json.Marshal(playerData)
+	if err != nil {
+		log.Printf("Error marshaling player message: %v", err)
+		return
 	}
+	h.publish(quizCode, envelope{Type: messageType, HostData: hostBytes, PlayerData: playerBytes, SpectatorData: playerBytes})
+}
 
-	log.Printf("Completed broadcasting message to all clients in room %s", quizCode)
+// publish sends a room message through the broker to every instance that
+// has clients in the room.
+func (h *Hub) publish(quizCode string, env envelope) {
+	payload, err := json.Marshal(env)
+	if err != nil {
+		log.Printf("Error marshaling %s message: %v", env.Type, err)
+		return
+	}
+	if err := h.broker.Publish(quizCode, payload); err != nil {
+		log.Printf("Error publishing %s message to quiz %s: %v", env.Type, quizCode, err)
+	}
 }
 
-// BroadcastMessage marshals the message and then broadcasts it.
+// BroadcastMessage marshals the message and then broadcasts it to every
+// client in the room. Use it only for payloads that are safe for every role;
+// anything carrying host-only fields goes through BroadcastByRole.
 func (h *Hub) BroadcastMessage(quizCode string, messageType string, data interface{}) {
 	log.Printf("BroadcastMessage called for quiz %s with type %s", quizCode, messageType)
+	h.broadcast(quizCode, messageType, data, data)
+}
 
-	msg := Message{
-		Type: messageType,
-		Data: data,
-	}
+// BroadcastByRole sends hostData to the host clients of a room and playerData
+// to everyone else, so fields such as correct answers never reach players.
+func (h *Hub) BroadcastByRole(quizCode string, messageType string, hostData, playerData interface{}) {
+	log.Printf("BroadcastByRole called for quiz %s with type %s", quizCode, messageType)
+	h.broadcast(quizCode, messageType, hostData, playerData)
+}
 
-	messageBytes, err := json.Marshal(msg)
+// BroadcastEach sends every user of a room, on any instance, their own
+// version of a message. data is called once per user with their role, and
+// once with user 0 and RoleSpectator for the room's spectators. The users are
+// those connected plus every participant of the session, so a player within
+// the reconnect grace period finds their copy in the log when they return.
+func (h *Hub) BroadcastEach(quizCode string, messageType string, data func(userID uint, role Role) interface{}) {
+	members, err := h.presence.Members(quizCode)
 	if err != nil {
-		log.Printf("Error marshaling message: %v", err)
+		log.Printf("Error listing members of quiz %s: %v", quizCode, err)
 		return
 	}
+	roles := make(map[uint]Role, len(members))
+	for _, member := range members {
+		if member.IsHost {
+			roles[member.UserID] = RoleHost
+		} else if _, ok := roles[member.UserID]; !ok {
+			roles[member.UserID] = RolePlayer
+		}
+	}
+	if h.quizService != nil {
+		participants, err := h.quizService.ParticipantIDs(quizCode)
+		if err != nil {
+			log.Printf("Error listing participants of quiz %s: %v", quizCode, err)
+		}
+		for _, userID := range participants {
+			if _, ok := roles[userID]; !ok {
+				roles[userID] = RolePlayer
+			}
+		}
+	}
 
-	log.Printf("Marshaled message: %s", string(messageBytes))
-	h.BroadcastToQuiz(quizCode, messageBytes)
+	userData := make(map[uint]json.RawMessage, len(roles))
+	for userID, role := range roles {
+		message, err := json.Marshal(data(userID, role))
+		if err != nil {
+			log.Printf("Error marshaling message for user %d: %v", userID, err)
+			continue
+		}
+		userData[userID] = message
+	}
+	spectatorData, err := json.Marshal(data(0, RoleSpectator))
+	if err != nil {
+		log.Printf("Error marshaling spectator message: %v", err)
+		spectatorData = nil
+	}
+	h.publish(quizCode, envelope{Type: messageType, UserData: userData, SpectatorData: spectatorData})
 }
 
-func (h *Hub) SendMessageToUser(userID uint, messageType string, data interface{}) {
-	h.mu.RLock()
-	client, exists := h.clientsByUser[userID] // Now this field exists
-	h.mu.RUnlock()
-	if !exists || client == nil {
-		log.Printf("No active client found for user %d", userID)
+// SendToSpectators sends a message to the spectator displays of a room only.
+func (h *Hub) SendToSpectators(quizCode string, messageType string, data interface{}) {
+	message, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Error marshaling spectator message: %v", err)
 		return
 	}
+	h.publish(quizCode, envelope{Type: messageType, SpectatorData: message})
+}
 
-	msg := Message{
-		Type: messageType,
-		Data: data,
+// SendMessageToUser sends a message to a player. A player who is
+// disconnected gets it when they reconnect with last_seq.
+func (h *Hub) SendMessageToUser(userID uint, messageType string, data interface{}) {
+	h.sendTo(userID, false, messageType, data)
+}
+
+// SendMessageToHost sends a message to the host connection of a user.
+func (h *Hub) SendMessageToHost(userID uint, messageType string, data interface{}) {
+	h.sendTo(userID, true, messageType, data)
+}
+
+// sendTo sends a message to one user in the room they last joined, on
+// whichever instance they are connected to. It is sequenced with the room's
+// other messages so it is replayed to them after a reconnect.
+func (h *Hub) sendTo(userID uint, isHost bool, messageType string, data interface{}) {
+	quizCode, err := h.presence.RoomOf(userID, isHost)
+	if err != nil || quizCode == "" {
+		log.Printf("No active client found for user %d: %v", userID, err)
+		return
 	}
-	messageBytes, err := json.Marshal(msg)
+	message, err := json.Marshal(data)
 	if err != nil {
 		log.Printf("Error marshaling message for user %d: %v", userID, err)
 		return
 	}
-
-	// Send the message bytes through the client's send channel.
-	select {
-	case client.send <- messageBytes:
-		log.Printf("Queued message for user %d", userID)
-	default:
-		log.Printf("Send channel full for user %d; unregistering client", userID)
-		h.unregister <- client
-	}
+	h.publish(quizCode, envelope{Type: messageType, UserData: map[uint]json.RawMessage{userID: message}})
 }
 
-func (h *Hub) RegisterClient(client *Client, quizCode string) {
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	log.Printf("Registering client %p for quiz %s", client, quizCode)
-
-	// Initialize room if it doesn't exist.
-	if _, ok := h.quizRooms[quizCode]; !ok {
-		h.quizRooms[quizCode] = make(map[*Client]bool)
-		log.Printf("Created new room for quiz %s", quizCode)
+// DisconnectUser removes a player from a room on every instance: their
+// connections get a "kicked" message saying why and are closed with the
+// given close code.
+func (h *Hub) DisconnectUser(quizCode string, userID uint, closeCode int, reason string) {
+	if len(reason) > maxCloseReason {
+		reason = reason[:maxCloseReason]
 	}
-
-	// Add client to room and to the overall clients map.
-	h.quizRooms[quizCode][client] = true
-	h.clients[client] = true
-
-	// Recalculate participant count excluding hosts.
-	count := 0
-	for c := range h.quizRooms[quizCode] {
-		if c.user != nil {
-			if _, isHost := h.hosts[c.user.UserID]; !isHost {
-				count++
-			}
-		}
+	if err := h.presence.Forget(quizCode, userID, false); err != nil {
+		log.Printf("Error forgetting room of user %d: %v", userID, err)
 	}
-	h.participants[quizCode] = count
-	log.Printf("Client %p registered for quiz %s. Participant count (excluding hosts): %d", client, quizCode, count)
-
-	// Broadcast updated participant count.
-	go h.BroadcastMessage(quizCode, "participant_update", map[string]interface{}{
-		"count": count,
+	message, err := json.Marshal(KickedMessage{
+		Reason: reason,
+		Banned: closeCode == CloseBanned,
+	})
+	if err != nil {
+		log.Printf("Error marshaling kicked message: %v", err)
+		return
+	}
+	h.publish(quizCode, envelope{
+		Type:       "kicked",
+		UserData:   map[uint]json.RawMessage{userID: message},
+		Disconnect: &disconnect{UserID: userID, CloseCode: closeCode, Reason: reason},
 	})
-	// Also broadcast the updated participant list.
-    go h.SendParticipantList(quizCode)
-}
-
-func (h *Hub) UnregisterClient(client *Client) {
-    h.mu.Lock()
-    quizCode := client.quizCode
-    
-    // Check if client exists in the quiz room
-    if room, exists := h.quizRooms[quizCode]; exists {
-        delete(room, client)
-        log.Printf("Client %p removed from quiz %s", client, quizCode)
-        
-        // Remove from global maps
-        delete(h.clients, client)
-        if client.user != nil {
-            delete(h.clientsByUser, client.user.UserID)
-            delete(h.hosts, client.user.UserID)
-        }
-        
-        // Recalculate participant count
-        count := 0
-        participants := make([]UserInfo, 0)
-        var hostInfo *UserInfo
-        
-        for c := range room {
-            if c.user != nil {
-                if c.isHost {
-                    hostInfo = c.user
-                } else {
-                    count++
-                    participants = append(participants, *c.user)
-                }
-            }
-        }
-        
-        h.participants[quizCode] = count
-        
-        // Update database if participant leaves
-        if !client.isHost && client.user != nil {
-            go h.removeParticipantFromDB(quizCode, client.user.UserID)
-        }
-        
-        // Close client channels
-        close(client.send)
-        close(client.done)
-        
-        // Release the lock before broadcasting
-        h.mu.Unlock()
-        
-        // Broadcast updated participant information
-        h.BroadcastMessage(quizCode, "participant_list", map[string]interface{}{
-            "participants": participants,
-            "count":       count,
-            "host":        hostInfo,
-        })
-        
-        h.BroadcastMessage(quizCode, "participant_update", map[string]interface{}{
-            "count": count,
-        })
-        
-        log.Printf("Participant updates broadcast for quiz %s", quizCode)
-    } else {
-        h.mu.Unlock()
-    }
 }
 
 // Helper method to remove participant from database
@@ -307,89 +407,39 @@ func (h *Hub) removeParticipantFromDB(quizCode string, userID uint) {
     }
 }
 
+// SendParticipantList sends the room the players and host connected to it on
+// any instance.
 func (h *Hub) SendParticipantList(quizCode string) {
-    h.mu.RLock()
-    room, exists := h.quizRooms[quizCode]
-    if !exists {
-        h.mu.RUnlock()
+    members, err := h.presence.Members(quizCode)
+    if err != nil {
+        log.Printf("Error listing members of quiz %s: %v", quizCode, err)
         return
     }
 
     participants := make([]UserInfo, 0)
     var hostInfo *UserInfo
 
-    for client := range room {
-        if client.user != nil {
-            if client.isHost {
-                hostInfo = client.user
-            } else {
-                participants = append(participants, *client.user)
-            }
+    for _, member := range members {
+        if member.IsHost {
+            host := member.UserInfo
+            hostInfo = &host
+        } else {
+            participants = append(participants, member.UserInfo)
         }
     }
-    h.mu.RUnlock()
 
     // Send both participant list and participant update
-    h.BroadcastMessage(quizCode, "participant_list", map[string]interface{}{
-        "participants": participants,
-        "count":       len(participants),
-        "host":        hostInfo,
+    h.BroadcastMessage(quizCode, "participant_list", ParticipantListMessage{
+        Participants: participants,
+        Count:        len(participants),
+        Host:         hostInfo,
     })
 
-    h.BroadcastMessage(quizCode, "participant_update", map[string]interface{}{
-        "count": len(participants),
+    h.BroadcastMessage(quizCode, "participant_update", ParticipantUpdateMessage{
+        Count: len(participants),
     })
 }
 
-
-// Run listens on the register and unregister channels and updates the hub state accordingly.
-func (h *Hub) Run() {
-	for {
-		select {
-		case client := <-h.register:
-			h.mu.Lock()
-			h.clients[client] = true
-			if client.quizCode != "" {
-				// Create the quiz room if it doesn't exist.
-				if _, exists := h.quizRooms[client.quizCode]; !exists {
-					h.quizRooms[client.quizCode] = make(map[*Client]bool)
-					log.Printf("Created room for quiz %s", client.quizCode)
-				}
-				// Add the client to the room.
-				h.quizRooms[client.quizCode][client] = true
-				h.participants[client.quizCode]++
-				log.Printf("Client %p added to quiz %s. Total: %d", client, client.quizCode, h.participants[client.quizCode])
-				// Broadcast updated participant count.
-				count := h.participants[client.quizCode]
-				go h.BroadcastMessage(client.quizCode, "participant_update", map[string]interface{}{
-					"count": count,
-				})
-			}
-			h.mu.Unlock()
-
-		case client := <-h.unregister:
-			h.mu.Lock()
-			if _, ok := h.clients[client]; ok {
-				if client.quizCode != "" {
-					if room, exists := h.quizRooms[client.quizCode]; exists {
-						delete(room, client)
-						h.participants[client.quizCode]--
-						log.Printf("Client %p left quiz %s. Remaining: %d", client, client.quizCode, h.participants[client.quizCode])
-						count := h.participants[client.quizCode]
-						go h.BroadcastMessage(client.quizCode, "participant_update", map[string]interface{}{
-							"count": count,
-						})
-					}
-				}
-				delete(h.clients, client)
-				close(client.send)
-				close(client.done)
-			}
-			h.mu.Unlock()
-		}
-	}
-}
-
 // NewClient creates a new Client instance.
 func NewClient(hub *Hub, conn *websocket.Conn, quizCode string) *Client {
 	return &Client{
@@ -397,29 +447,132 @@ func NewClient(hub *Hub, conn *websocket.Conn, quizCode string) *Client {
 		conn:     conn,
 		send:     make(chan []byte, 256),
 		quizCode: quizCode,
-		done:     make(chan struct{}),
 	}
 }
 
 // HandleWebSocket upgrades the HTTP connection to a WebSocket and registers the client.
 func (h *Hub) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	quizCode := vars["quizCode"]
-	if quizCode == "" {
+	code := vars["quizCode"]
+	if code == "" {
 		http.Error(w, "Missing quiz code", http.StatusBadRequest)
 		return
 	}
 
-	conn, err := upgrader.Upgrade(w, r, nil)
+	if h.quizService == nil {
+		http.Error(w, "Quiz service not configured", http.StatusInternalServerError)
+		return
+	}
+
+	// A display with a screen token watches as a spectator; anyone else
+	// connects as the user of their token.
+	var userID uint
+	var username, screenCode string
+	var spectator bool
+	var err error
+	if h.authenticateScreen != nil {
+		screenCode, spectator, err = h.authenticateScreen(r)
+		username = "Screen"
+	}
+	if err == nil && !spectator {
+		if h.authenticate == nil {
+			http.Error(w, "WebSocket authentication not configured", http.StatusInternalServerError)
+			return
+		}
+		userID, username, err = h.authenticate(r)
+	}
+	if err != nil {
+		log.Printf("WebSocket authentication failed for %s: %v", code, err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// The code is a session join code or a quiz code; either way the room is
+	// keyed by the join code of the session it resolves to.
+	session, err := h.quizService.ResolveSession(code)
+	if err != nil {
+		log.Printf("Error resolving session for %s: %v", code, err)
+		http.Error(w, "Quiz not found", http.StatusNotFound)
+		return
+	}
+	quizCode := session.JoinCode
+
+	if spectator && screenCode != quizCode {
+		log.Printf("Refusing screen token for %s on %s", screenCode, quizCode)
+		http.Error(w, "Screen token is for another session", http.StatusForbidden)
+		return
+	}
+
+	// Determine host status before the connection is registered so the
+	// identity never depends on anything the client sends later.
+	isHost := !spectator && session.HostID == userID
+	if !isHost && !spectator {
+		banned, err := h.quizService.IsBanned(session.ID, userID)
+		if err != nil {
+			log.Printf("Error checking ban of user %d in %s: %v", userID, quizCode, err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if banned {
+			log.Printf("Refusing WebSocket for user %d banned from %s", userID, quizCode)
+			http.Error(w, "Banned from this session", http.StatusForbidden)
+			return
+		}
+	}
+
+	// Settle the protocol version before upgrading, so a client that needs a
+	// version this server does not speak gets a plain HTTP error.
+	protocol, err := negotiateProtocol(r.URL.Query().Get("protocol"))
+	if err != nil {
+		log.Printf("Refusing WebSocket for user %d in %s: %v", userID, quizCode, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade error: %v", err)
 		return
 	}
 
 	client := NewClient(h, conn, quizCode)
-	log.Printf("Created new WebSocket client %p for quiz %s", client, quizCode)
-
-	h.RegisterClient(client, quizCode)
+	client.user = &UserInfo{UserID: userID, Username: username}
+	client.isHost = isHost
+	client.spectator = spectator
+	client.protocol = protocol
+	log.Printf("Created new WebSocket client %p for quiz %s (user %d, %s, protocol %d)", client, quizCode, userID, client.role(), protocol)
+
+	// A reconnecting client sends the seq of the last message it received.
+	lastSeq, err := strconv.ParseUint(r.URL.Query().Get("last_seq"), 10, 64)
+	resuming := err == nil
+
+	info := SessionMessage{
+		SessionID: session.ID,
+		JoinCode:  session.JoinCode,
+		QuizID:    session.QuizID,
+		State:     session.State,
+		IsHost:    isHost,
+		Role:      client.role(),
+		Protocol:  protocol,
+	}
+	if isHost {
+		scoring := session.Scoring.WithDefaults()
+		info.Scoring = &scoring
+	}
+	client.sendMessage("session", info)
+	joined := make(chan bool, 1)
+	h.post(quizCode, func(r *room) {
+		joined <- r.join(client, lastSeq, resuming)
+	})
+	if replayed := <-joined; !replayed && client.role() == RolePlayer {
+		// Too much was missed, or nothing is known of the client: send the
+		// player a snapshot of where they are instead.
+		h.post(quizCode, func(*room) {
+			if err := h.quizService.ResumePlayer(quizCode, userID); err != nil {
+				log.Printf("Error resuming user %d in %s: %v", userID, quizCode, err)
+			}
+		})
+	}
 
 	// Start the pumps in separate goroutines
 	go client.writePump()
@@ -429,7 +582,7 @@ func (h *Hub) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 // readPump continuously reads messages from the WebSocket connection.
 func (c *Client) readPump() {
 	defer func() {
-		c.hub.unregister <- c
+		c.room.post(func(r *room) { r.leave(c) })
 		c.conn.Close()
 	}()
 	c.conn.SetReadLimit(maxMessageSize)
@@ -447,96 +600,181 @@ func (c *Client) readPump() {
 			break
 		}
 		log.Printf("Received from client %p: %s", c, string(message))
-		c.handleMessage(message)
+		c.room.post(func(r *room) { r.handle(c, message) })
 	}
 }
 
+// clientMessage is a message as a client sends it. Its data is decoded into
+// the payload type of the message once the type is known.
+type clientMessage struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// handleMessage runs a message from the client on its room's goroutine. A
+// message that cannot be read or is refused gets an error reply.
 func (c *Client) handleMessage(message []byte) {
-	var msg Message
+	var msg clientMessage
 	if err := json.Unmarshal(message, &msg); err != nil {
 		log.Printf("Error unmarshaling message: %v", err)
+		c.sendError(&ProtocolError{Code: ErrorBadMessage, Message: "message is not valid JSON"}, "")
 		return
 	}
 
 	log.Printf("Client %p handling message type: %s", c, msg.Type)
+	if err := c.dispatch(msg); err != nil {
+		log.Printf("Error handling %s: %v", msg.Type, err)
+		c.sendError(err, msg.Type)
+	}
+}
 
-	switch msg.Type {
-	case "join_quiz":
-		if data, ok := msg.Data.(map[string]interface{}); ok {
-			if user, ok := data["user"].(map[string]interface{}); ok {
-				c.user = &UserInfo{
-					UserID:   uint(user["userId"].(float64)),
-					Username: user["username"].(string),
-					Email: func() string {
-						if email, ok := user["email"].(string); ok {
-							return email
-						}
-						return ""
-					}(),
-				}
-				log.Printf("User joined: %+v", c.user)
+// dispatch decodes and checks the data of a client message and carries it out.
+func (c *Client) dispatch(msg clientMessage) error {
+	if c.hub.quizService == nil {
+		log.Printf("Quiz service not initialized")
+		return &ProtocolError{Code: ErrorInternal, Message: "quiz service not initialized"}
+	}
 
-				// Determine host status using quiz service
-				isHost, err := c.hub.checkIfHost(c.quizCode, c.user.UserID)
-				if err != nil {
-					log.Printf("Error checking host status for quiz %s: %v", c.quizCode, err)
-					isHost = false // default to non-host on error
-				}
-				c.isHost = isHost
-
-				c.hub.mu.Lock()
-				if isHost {
-					// Host: add to a dedicated hosts map if desired (or simply mark the client)
-					if c.hub.hosts == nil {
-						c.hub.hosts = make(map[uint]*Client)
-					}
-					c.hub.hosts[c.user.UserID] = c
-					log.Printf("User %d identified as host; will not receive participant events.", c.user.UserID)
-				} else {
-					// Regular participant: add to clientsByUser map.
-					c.hub.clientsByUser[c.user.UserID] = c
-				}
-				c.hub.mu.Unlock()
-				go c.hub.SendParticipantList(c.quizCode)
+	// Spectators only watch.
+	if c.spectator && msg.Type != "join_quiz" {
+		return &ProtocolError{Code: ErrorReadOnly, Message: "spectators cannot send " + msg.Type}
+	}
 
-			}
-		}
+	switch msg.Type {
+	case "join_quiz":
+		// Identity is bound at the handshake; any user fields in the payload
+		// are ignored. The message only asks for a fresh participant list.
+		log.Printf("User %d joined quiz %s", c.user.UserID, c.quizCode)
+		c.hub.SendParticipantList(c.quizCode)
+		return nil
 
 	case "start_quiz":
 		log.Printf("Quiz start message received for quiz %s", c.quizCode)
+		return nil
 
 	case "answer_submitted":
-		if data, ok := msg.Data.(map[string]interface{}); ok {
-			quizCode := data["quizCode"].(string)
-			questionId := uint(data["questionId"].(float64))
-			answer := data["answer"].(string)
-			userId := uint(data["userId"].(float64))
-
-			log.Printf("Answer submitted for quiz %s: user %d, question %d, answer: %s",
-				quizCode, userId, questionId, answer)
-
-			// Broadcast answer submission to all participants (both hosts and players may receive this if needed)
-			c.hub.BroadcastMessage(quizCode, "answer_update", map[string]interface{}{
-				"userId":     userId,
-				"questionId": questionId,
-			})
+		var data AnswerSubmittedMessage
+		if err := decodeData(msg, &data); err != nil {
+			return err
+		}
+		if data.QuestionID == 0 {
+			return invalidData("questionId is required")
 		}
+		log.Printf("Answer submitted for quiz %s: user %d, question %d", c.quizCode, c.user.UserID, data.QuestionID)
+
+		// Broadcast answer submission to all participants (both hosts and players may receive this if needed)
+		c.hub.BroadcastMessage(c.quizCode, "answer_update", AnswerUpdateMessage{
+			UserID:     c.user.UserID,
+			QuestionID: data.QuestionID,
+		})
+		return nil
+
+	case "reveal_results":
+		return c.hub.quizService.RevealResults(c.quizCode, c.user.UserID)
+
+	case "pause_quiz", "resume_quiz", "skip_question", "end_quiz":
+		return c.hostControl(msg.Type)
+
+	case "kick_participant", "ban_participant":
+		var data RemoveParticipantMessage
+		if err := decodeData(msg, &data); err != nil {
+			return err
+		}
+		if data.UserID == 0 {
+			return invalidData("userId is required")
+		}
+		remove := c.hub.quizService.KickParticipant
+		if msg.Type == "ban_participant" {
+			remove = c.hub.quizService.BanParticipant
+		}
+		return remove(c.quizCode, c.user.UserID, data.UserID, data.Reason)
 
 	case "next_question":
-		if data, ok := msg.Data.(map[string]interface{}); ok {
-			quizCode := data["quizCode"].(string)
-			currentIndex := int(data["currentIndex"].(float64))
-
-			if c.hub.quizService != nil {
-				log.Printf("Processing next question request for quiz %s, current index: %d", quizCode, currentIndex)
-				if err := c.hub.quizService.HandleNextQuestion(quizCode, currentIndex); err != nil {
-					log.Printf("Error handling next question: %v", err)
-				}
-			} else {
-				log.Printf("Quiz service not initialized")
-			}
+		var data NextQuestionMessage
+		if err := decodeData(msg, &data); err != nil {
+			return err
 		}
+		if data.CurrentIndex < 0 {
+			return invalidData("currentIndex must not be negative")
+		}
+		log.Printf("Processing next question request for quiz %s, current index: %d", c.quizCode, data.CurrentIndex)
+		return c.hub.quizService.HandleNextQuestion(c.quizCode, c.user.UserID, data.CurrentIndex)
+	}
+
+	return &ProtocolError{Code: ErrorUnknownType, Message: fmt.Sprintf("unknown message type %q", msg.Type)}
+}
+
+// decodeData decodes the data of a message into its payload type. Missing
+// data leaves the payload empty.
+func decodeData(msg clientMessage, payload interface{}) error {
+	if len(msg.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(msg.Data, payload); err != nil {
+		return invalidData("invalid %s data: %v", msg.Type, err)
+	}
+	return nil
+}
+
+// role returns how the client takes part in its room.
+func (c *Client) role() Role {
+	switch {
+	case c.spectator:
+		return RoleSpectator
+	case c.isHost:
+		return RoleHost
+	}
+	return RolePlayer
+}
+
+// hostControl runs one of the host's pause, resume, skip and end controls.
+// The service checks that the client is the session's host.
+func (c *Client) hostControl(messageType string) error {
+	var control func(quizCode string, userID uint) (*models.QuizSession, error)
+	switch messageType {
+	case "pause_quiz":
+		control = c.hub.quizService.PauseSession
+	case "resume_quiz":
+		control = c.hub.quizService.ResumeSession
+	case "skip_question":
+		control = c.hub.quizService.SkipQuestion
+	default:
+		control = c.hub.quizService.EndSession
+	}
+	_, err := control(c.quizCode, c.user.UserID)
+	return err
+}
+
+// sendMessage queues a message for this client only. Once the client has
+// joined its room it is called on the room's goroutine.
+func (c *Client) sendMessage(messageType string, data interface{}) {
+	messageBytes, err := json.Marshal(Message{Type: messageType, Data: data})
+	if err != nil {
+		log.Printf("Error marshaling %s message: %v", messageType, err)
+		return
 	}
+	if c.room != nil {
+		c.room.queue(c, messageBytes)
+		return
+	}
+	select {
+	case c.send <- messageBytes:
+	default:
+		log.Printf("Send channel full for client %p; dropping %s message", c, messageType)
+	}
+}
+
+// sendError reports a refused message back to the client that sent it,
+// with a code saying why. request is the type of that message, if known.
+func (c *Client) sendError(err error, request string) {
+	code := ErrorInternal
+	var protocolErr *ProtocolError
+	if errors.As(err, &protocolErr) {
+		code = protocolErr.Code
+	} else if c.hub.errorCode != nil {
+		code = c.hub.errorCode(err)
+	}
+	c.sendMessage("error", ErrorMessage{Code: code, Message: err.Error(), Request: request})
 }
 
 func (c *Client) writePump() {
@@ -551,7 +789,11 @@ func (c *Client) writePump() {
 		case message, ok := <-c.send:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				closeMessage := c.closeMessage
+				if closeMessage == nil {
+					closeMessage = []byte{}
+				}
+				c.conn.WriteMessage(websocket.CloseMessage, closeMessage)
 				return
 			}
 
@@ -579,8 +821,6 @@ func (c *Client) writePump() {
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
-		case <-c.done:
-			return
 		}
 	}
 }
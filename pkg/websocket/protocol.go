@@ -0,0 +1,198 @@
+package websocket
+
+import (
+	"fmt"
+	"quiz-system/internal/models"
+	"strconv"
+	"strings"
+)
+
+// Versions of the message protocol this server speaks. A client asks for the
+// versions it understands with ?protocol=; the session message says which
+// one the connection uses.
+const (
+	ProtocolVersion    = 1
+	MinProtocolVersion = 1
+)
+
+// negotiateProtocol picks the newest version the server supports from a
+// comma-separated list the client sent. No list means the current version.
+func negotiateProtocol(requested string) (int, error) {
+	if requested == "" {
+		return ProtocolVersion, nil
+	}
+	chosen := 0
+	for _, field := range strings.Split(requested, ",") {
+		version, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return 0, fmt.Errorf("invalid protocol version %q", field)
+		}
+		if version >= MinProtocolVersion && version <= ProtocolVersion && version > chosen {
+			chosen = version
+		}
+	}
+	if chosen == 0 {
+		return 0, fmt.Errorf("unsupported protocol version %s; this server speaks %d to %d", requested, MinProtocolVersion, ProtocolVersion)
+	}
+	return chosen, nil
+}
+
+// ErrorCode identifies why a client's message was refused.
+type ErrorCode string
+
+const (
+	// Problems with the message itself.
+	ErrorBadMessage  ErrorCode = "bad_message"  // not a JSON message
+	ErrorUnknownType ErrorCode = "unknown_type" // no such message type
+	ErrorInvalidData ErrorCode = "invalid_data" // data missing, mistyped or out of range
+	ErrorReadOnly    ErrorCode = "read_only"    // spectators cannot send it
+
+	// Requests the session refused.
+	ErrorNotHost          ErrorCode = "not_host"
+	ErrorBanned           ErrorCode = "banned"
+	ErrorNotFound         ErrorCode = "not_found"
+	ErrorInvalidState     ErrorCode = "invalid_state"
+	ErrorWrongPacing      ErrorCode = "wrong_pacing"
+	ErrorNoActiveQuestion ErrorCode = "no_active_question"
+	ErrorAlreadyAnswered  ErrorCode = "already_answered"
+	ErrorAnswerTooLate    ErrorCode = "answer_too_late"
+	ErrorResultsShown     ErrorCode = "results_shown"
+	ErrorEliminated       ErrorCode = "eliminated"
+	ErrorNoQuestions      ErrorCode = "no_questions"
+	ErrorInternal         ErrorCode = "internal"
+)
+
+// ErrorCodes lists every error code, for the protocol schema.
+var ErrorCodes = []ErrorCode{
+	ErrorBadMessage, ErrorUnknownType, ErrorInvalidData, ErrorReadOnly,
+	ErrorNotHost, ErrorBanned, ErrorNotFound, ErrorInvalidState, ErrorWrongPacing,
+	ErrorNoActiveQuestion, ErrorAlreadyAnswered, ErrorAnswerTooLate, ErrorResultsShown, ErrorEliminated,
+	ErrorNoQuestions, ErrorInternal,
+}
+
+// ProtocolError is a refused client message with the code sent back for it.
+type ProtocolError struct {
+	Code    ErrorCode
+	Message string
+}
+
+func (e *ProtocolError) Error() string {
+	return e.Message
+}
+
+func invalidData(format string, args ...interface{}) *ProtocolError {
+	return &ProtocolError{Code: ErrorInvalidData, Message: fmt.Sprintf(format, args...)}
+}
+
+// Messages clients send.
+
+// JoinQuizMessage asks for a fresh participant list. Identity comes from the
+// handshake, so any user fields are ignored.
+type JoinQuizMessage struct{}
+
+// StartQuizMessage is accepted for older clients and does nothing; quizzes are
+// started over the REST API.
+type StartQuizMessage struct{}
+
+// AnswerSubmittedMessage tells the room a player answered a question. The
+// answer itself goes to the REST API.
+type AnswerSubmittedMessage struct {
+	QuestionID uint `json:"questionId"`
+}
+
+// NextQuestionMessage moves a host-paced session past the question at
+// CurrentIndex (host only).
+type NextQuestionMessage struct {
+	CurrentIndex int `json:"currentIndex"`
+}
+
+// HostControlMessage is the empty payload of reveal_results, pause_quiz,
+// resume_quiz, skip_question and end_quiz (host only).
+type HostControlMessage struct{}
+
+// RemoveParticipantMessage kicks or bans a player (host only).
+type RemoveParticipantMessage struct {
+	UserID uint   `json:"userId"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Messages the hub sends.
+
+// SessionMessage is the first message of every connection. Role says whether
+// it is the host, a player or a spectator.
+type SessionMessage struct {
+	SessionID uint                  `json:"sessionId"`
+	JoinCode  string                `json:"joinCode"`
+	QuizID    uint                  `json:"quizId"`
+	State     models.SessionState   `json:"state"`
+	IsHost    bool                  `json:"isHost"`
+	Role      Role                  `json:"role"`
+	Protocol  int                   `json:"protocol"`
+	Scoring   *models.ScoringConfig `json:"scoring,omitempty"` // host only
+}
+
+// ErrorMessage reports a client message that was refused. Request is the type
+// of that message, when it could be read.
+type ErrorMessage struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+	Request string    `json:"request,omitempty"`
+}
+
+// ParticipantListMessage lists who is connected to the room.
+type ParticipantListMessage struct {
+	Participants []UserInfo `json:"participants"`
+	Count        int        `json:"count"`
+	Host         *UserInfo  `json:"host"`
+}
+
+// ParticipantUpdateMessage carries the number of connected players.
+type ParticipantUpdateMessage struct {
+	Count int `json:"count"`
+}
+
+// AnswerUpdateMessage tells the room a player answered.
+type AnswerUpdateMessage struct {
+	UserID     uint `json:"userId"`
+	QuestionID uint `json:"questionId"`
+}
+
+// KickedMessage tells a player the host removed them, just before their
+// connection is closed.
+type KickedMessage struct {
+	Reason string `json:"reason"`
+	Banned bool   `json:"banned"`
+}
+
+// MessageSpec describes one message type of the protocol.
+type MessageSpec struct {
+	Type        string
+	Description string
+	Data        interface{} // a value of the payload type; nil for no payload
+}
+
+// ClientMessages are the messages clients send.
+var ClientMessages = []MessageSpec{
+	{Type: "join_quiz", Description: "Ask for a fresh participant list.", Data: JoinQuizMessage{}},
+	{Type: "start_quiz", Description: "Ignored; start quizzes over the REST API.", Data: StartQuizMessage{}},
+	{Type: "answer_submitted", Description: "Tell the room an answer was submitted.", Data: AnswerSubmittedMessage{}},
+	{Type: "next_question", Description: "Move a host-paced session on (host only).", Data: NextQuestionMessage{}},
+	{Type: "reveal_results", Description: "Show the results of the current question (host only).", Data: HostControlMessage{}},
+	{Type: "pause_quiz", Description: "Pause the session (host only).", Data: HostControlMessage{}},
+	{Type: "resume_quiz", Description: "Resume a paused session (host only).", Data: HostControlMessage{}},
+	{Type: "skip_question", Description: "Skip the current question (host only).", Data: HostControlMessage{}},
+	{Type: "end_quiz", Description: "End the session early (host only).", Data: HostControlMessage{}},
+	{Type: "kick_participant", Description: "Remove a player (host only).", Data: RemoveParticipantMessage{}},
+	{Type: "ban_participant", Description: "Remove a player and keep them out (host only).", Data: RemoveParticipantMessage{}},
+}
+
+// ServerMessages are the messages the hub itself sends. The quiz service
+// lists its own.
+var ServerMessages = []MessageSpec{
+	{Type: "session", Description: "First message of every connection.", Data: SessionMessage{}},
+	{Type: "error", Description: "A client message was refused.", Data: ErrorMessage{}},
+	{Type: "participant_list", Description: "Who is connected to the room.", Data: ParticipantListMessage{}},
+	{Type: "participant_update", Description: "Number of connected players.", Data: ParticipantUpdateMessage{}},
+	{Type: "answer_update", Description: "A player answered.", Data: AnswerUpdateMessage{}},
+	{Type: "kicked", Description: "The host removed this player.", Data: KickedMessage{}},
+}
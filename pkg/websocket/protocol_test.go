@@ -0,0 +1,50 @@
+package websocket
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestNegotiateProtocol(t *testing.T) {
+	current := strconv.Itoa(ProtocolVersion)
+	newer := strconv.Itoa(ProtocolVersion + 1)
+	older := strconv.Itoa(MinProtocolVersion - 1)
+
+	tests := []struct {
+		name      string
+		requested string
+		want      int
+		wantErr   bool
+	}{
+		{"nothing requested", "", ProtocolVersion, false},
+		{"current version", current, ProtocolVersion, false},
+		{"oldest supported version", strconv.Itoa(MinProtocolVersion), MinProtocolVersion, false},
+		{"newest of several", current + "," + newer, ProtocolVersion, false},
+		{"order does not matter", newer + "," + current, ProtocolVersion, false},
+		{"spaces around versions", " " + current + " , " + newer + " ", ProtocolVersion, false},
+		{"only a newer version", newer, 0, true},
+		{"only an older version", older, 0, true},
+		{"none supported", older + "," + newer, 0, true},
+		{"not a number", "v1", 0, true},
+		{"one bad entry", current + ",x", 0, true},
+		{"empty entry", current + ",", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := negotiateProtocol(tt.requested)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("negotiateProtocol(%q) = %d, want an error", tt.requested, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("negotiateProtocol(%q) returned error %v", tt.requested, err)
+			}
+			if got != tt.want {
+				t.Errorf("negotiateProtocol(%q) = %d, want %d", tt.requested, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// presenceTTL keeps the presence keys of abandoned rooms from piling up.
+const presenceTTL = 24 * time.Hour
+
+func roomChannel(quizCode string) string      { return "ws:room:" + quizCode }
+func roomSeqKey(quizCode string) string       { return "ws:seq:" + quizCode }
+func presenceKey(quizCode string) string      { return "presence:" + quizCode }
+func presenceCountKey(quizCode string) string { return "presence:" + quizCode + ":connections" }
+
+func presenceField(userID uint, isHost bool) string {
+	if isHost {
+		return fmt.Sprintf("host:%d", userID)
+	}
+	return fmt.Sprintf("player:%d", userID)
+}
+
+func presenceUserKey(userID uint, isHost bool) string {
+	return "presence:user:" + presenceField(userID, isHost)
+}
+
+// publishScript numbers a room message and publishes it in one step, so the
+// order messages are published in is their sequence order.
+var publishScript = redis.NewScript(`
+local seq = redis.call('INCR', KEYS[1])
+redis.call('EXPIRE', KEYS[1], ARGV[2])
+redis.call('PUBLISH', KEYS[2], seq .. '\n' .. ARGV[1])
+return seq
+`)
+
+// RedisBroker carries room messages between instances over Redis pub/sub.
+// Each instance holds one subscription connection for all the rooms it follows.
+type RedisBroker struct {
+	client *redis.Client
+	ctx    context.Context
+	pubsub *redis.PubSub
+	subs   *subscribers
+	mu     sync.Mutex // serialises changes to the subscribed channels
+}
+
+// NewRedisBroker starts a broker on the given client.
+func NewRedisBroker(client *redis.Client) *RedisBroker {
+	b := &RedisBroker{
+		client: client,
+		ctx:    context.Background(),
+		subs:   newSubscribers(),
+	}
+	b.pubsub = client.Subscribe(b.ctx)
+	go b.listen()
+	return b
+}
+
+func (b *RedisBroker) Publish(quizCode string, payload []byte) error {
+	keys := []string{roomSeqKey(quizCode), roomChannel(quizCode)}
+	return publishScript.Run(b.ctx, b.client, keys, payload, int(presenceTTL.Seconds())).Err()
+}
+
+func (b *RedisBroker) Subscribe(quizCode string, deliver func(seq uint64, payload []byte)) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id, first := b.subs.add(quizCode, deliver)
+	if first {
+		if err := b.pubsub.Subscribe(b.ctx, roomChannel(quizCode)); err != nil {
+			b.subs.remove(quizCode, id)
+			return nil, err
+		}
+	}
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if b.subs.remove(quizCode, id) {
+			if err := b.pubsub.Unsubscribe(b.ctx, roomChannel(quizCode)); err != nil {
+				log.Printf("Error unsubscribing from room %s: %v", quizCode, err)
+			}
+		}
+	}, nil
+}
+
+// listen hands each published message to the subscribers of its room.
+func (b *RedisBroker) listen() {
+	for msg := range b.pubsub.Channel() {
+		quizCode := msg.Channel[len(roomChannel("")):]
+		header, payload, found := bytes.Cut([]byte(msg.Payload), []byte("\n"))
+		if !found {
+			log.Printf("Malformed message on %s", msg.Channel)
+			continue
+		}
+		seq, err := strconv.ParseUint(string(header), 10, 64)
+		if err != nil {
+			log.Printf("Malformed sequence number on %s: %v", msg.Channel, err)
+			continue
+		}
+		b.subs.deliver(quizCode, seq, payload)
+	}
+}
+
+// leaveScript drops one connection of a member and removes the member once
+// their last connection has left.
+var leaveScript = redis.NewScript(`
+local left = redis.call('HINCRBY', KEYS[2], ARGV[1], -1)
+if left <= 0 then
+	redis.call('HDEL', KEYS[2], ARGV[1])
+	redis.call('HDEL', KEYS[1], ARGV[1])
+end
+return left
+`)
+
+// forgetScript deletes a user's last room if it is still the given room.
+var forgetScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// RedisPresence keeps room membership in Redis hashes shared by all instances.
+type RedisPresence struct {
+	client *redis.Client
+	ctx    context.Context
+}
+
+func NewRedisPresence(client *redis.Client) *RedisPresence {
+	return &RedisPresence{client: client, ctx: context.Background()}
+}
+
+func (p *RedisPresence) Join(quizCode string, user UserInfo, isHost bool) error {
+	member, err := json.Marshal(Member{UserInfo: user, IsHost: isHost})
+	if err != nil {
+		return err
+	}
+	field := presenceField(user.UserID, isHost)
+	_, err = p.client.TxPipelined(p.ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(p.ctx, presenceKey(quizCode), field, member)
+		pipe.HIncrBy(p.ctx, presenceCountKey(quizCode), field, 1)
+		pipe.Expire(p.ctx, presenceKey(quizCode), presenceTTL)
+		pipe.Expire(p.ctx, presenceCountKey(quizCode), presenceTTL)
+		pipe.Set(p.ctx, presenceUserKey(user.UserID, isHost), quizCode, presenceTTL)
+		return nil
+	})
+	return err
+}
+
+func (p *RedisPresence) Leave(quizCode string, userID uint, isHost bool) error {
+	keys := []string{presenceKey(quizCode), presenceCountKey(quizCode)}
+	return leaveScript.Run(p.ctx, p.client, keys, presenceField(userID, isHost)).Err()
+}
+
+func (p *RedisPresence) Members(quizCode string) ([]Member, error) {
+	values, err := p.client.HVals(p.ctx, presenceKey(quizCode)).Result()
+	if err != nil {
+		return nil, err
+	}
+	members := make([]Member, 0, len(values))
+	for _, value := range values {
+		var member Member
+		if err := json.Unmarshal([]byte(value), &member); err != nil {
+			log.Printf("Skipping malformed presence entry in %s: %v", quizCode, err)
+			continue
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+func (p *RedisPresence) RoomOf(userID uint, isHost bool) (string, error) {
+	quizCode, err := p.client.Get(p.ctx, presenceUserKey(userID, isHost)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return quizCode, err
+}
+
+func (p *RedisPresence) Forget(quizCode string, userID uint, isHost bool) error {
+	keys := []string{presenceUserKey(userID, isHost)}
+	return forgetScript.Run(p.ctx, p.client, keys, quizCode).Err()
+}
@@ -0,0 +1,311 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// room is one quiz room on this instance. A single goroutine owns it: joins,
+// leaves, player messages, broker deliveries and timer ticks are queued on
+// its mailbox and run one at a time, in order, so nothing else touches its
+// clients, event log or grace timers. The goroutine stops once the room has
+// no clients, no pending removals and nothing queued. A room that only runs
+// timer ticks does not follow the broker.
+type room struct {
+	hub      *Hub
+	quizCode string
+
+	// Owned by the room goroutine.
+	clients     map[*Client]bool
+	log         *eventLog
+	removals    map[uint]*time.Timer // grace timers of players whose connection dropped
+	unsubscribe func()
+
+	mu     sync.Mutex // guards ops and closed
+	ops    []func(*room)
+	closed bool
+	wake   chan struct{}
+}
+
+func newRoom(hub *Hub, quizCode string) *room {
+	return &room{
+		hub:      hub,
+		quizCode: quizCode,
+		clients:  make(map[*Client]bool),
+		log:      newEventLog(),
+		removals: make(map[uint]*time.Timer),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// post queues an operation for the room goroutine. It never blocks, so it is
+// safe to call from the room goroutine itself. It reports false once the room
+// has closed.
+func (r *room) post(op func(*room)) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return false
+	}
+	r.ops = append(r.ops, op)
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// take returns the queued operations and empties the mailbox.
+func (r *room) take() []func(*room) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ops := r.ops
+	r.ops = nil
+	return ops
+}
+
+// run is the room goroutine.
+func (r *room) run() {
+	log.Printf("Room %s started", r.quizCode)
+	for range r.wake {
+		for _, op := range r.take() {
+			op(r)
+		}
+		if len(r.clients) == 0 && len(r.removals) == 0 && r.hub.closeRoom(r) {
+			break
+		}
+	}
+	if r.unsubscribe != nil {
+		log.Printf("No clients left in quiz %s; unsubscribing", r.quizCode)
+		r.unsubscribe()
+	}
+	log.Printf("Room %s stopped", r.quizCode)
+}
+
+// subscribe starts following the room's messages on the broker.
+func (r *room) subscribe() {
+	cancel, err := r.hub.broker.Subscribe(r.quizCode, func(seq uint64, payload []byte) {
+		r.post(func(r *room) { r.deliver(seq, payload) })
+	})
+	if err != nil {
+		log.Printf("Error subscribing to quiz %s: %v", r.quizCode, err)
+		return
+	}
+	r.unsubscribe = cancel
+}
+
+// join adds a client to the room and, if the room's log still holds every
+// message after lastSeq, queues those the client should have received. It
+// reports whether the client was caught up this way; otherwise it needs a
+// full state snapshot.
+func (r *room) join(c *Client, lastSeq uint64, resuming bool) bool {
+	log.Printf("Registering client %p for quiz %s", c, r.quizCode)
+	if r.unsubscribe == nil {
+		r.subscribe()
+	}
+	c.room = r
+	r.clients[c] = true
+	if c.role() == RolePlayer {
+		r.cancelRemoval(c.user.UserID)
+	}
+	// Spectators are not participants, so they are left out of presence.
+	if !c.spectator {
+		if err := r.hub.presence.Join(r.quizCode, *c.user, c.isHost); err != nil {
+			log.Printf("Error recording presence of user %d in quiz %s: %v", c.user.UserID, r.quizCode, err)
+		}
+	}
+	log.Printf("Client %p registered for quiz %s", c, r.quizCode)
+
+	// Broadcast the updated participant list and count.
+	r.hub.SendParticipantList(r.quizCode)
+
+	if !resuming || !r.log.covers(lastSeq) {
+		return false
+	}
+	events := r.log.after(lastSeq)
+	log.Printf("Replaying %d events of quiz %s to client %p after seq %d", len(events), r.quizCode, c, lastSeq)
+	for _, event := range events {
+		if message := event.messageFor(c); message != nil {
+			r.queue(c, message)
+		}
+	}
+	return true
+}
+
+// leave removes a client whose connection is gone. A player keeps their place
+// for the reconnect grace period.
+func (r *room) leave(c *Client) {
+	r.remove(c, nil, true)
+}
+
+// remove takes a client out of the room and closes its send channel, after
+// which its write pump sends closeMessage and closes the connection. Only
+// the room goroutine closes send, and only while the client is in the room,
+// so it is closed once.
+func (r *room) remove(c *Client, closeMessage []byte, keepPlace bool) {
+	if !r.clients[c] {
+		return
+	}
+	delete(r.clients, c)
+	log.Printf("Client %p removed from quiz %s", c, r.quizCode)
+	c.closeMessage = closeMessage
+	close(c.send)
+
+	if c.spectator {
+		return
+	}
+	if keepPlace {
+		r.scheduleRemoval(c)
+	}
+	if err := r.hub.presence.Leave(r.quizCode, c.user.UserID, c.isHost); err != nil {
+		log.Printf("Error removing presence of user %d in quiz %s: %v", c.user.UserID, r.quizCode, err)
+	}
+	r.hub.SendParticipantList(r.quizCode)
+}
+
+// handle runs a message a client sent, unless the client has left the room.
+// A message that panics gets an internal error reply instead of taking the
+// room down.
+func (r *room) handle(c *Client, message []byte) {
+	if !r.clients[c] {
+		return
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("Recovered from panic handling message of client %p in quiz %s: %v", c, r.quizCode, p)
+			c.sendError(&ProtocolError{Code: ErrorInternal, Message: "internal error"}, "")
+		}
+	}()
+	c.handleMessage(message)
+}
+
+// deliver records a room message the broker numbered and queues it on the
+// room's clients.
+func (r *room) deliver(seq uint64, payload []byte) {
+	var env envelope
+	if err := json.Unmarshal(payload, &env); err != nil {
+		log.Printf("Error decoding message #%d of quiz %s: %v", seq, r.quizCode, err)
+		return
+	}
+	event := loggedEvent{seq: seq}
+	if env.HostData != nil {
+		event.host = encode(seq, env.Type, env.HostData)
+	}
+	if env.PlayerData != nil {
+		event.player = encode(seq, env.Type, env.PlayerData)
+	}
+	if env.SpectatorData != nil {
+		event.spectator = encode(seq, env.Type, env.SpectatorData)
+	}
+	if len(env.UserData) > 0 {
+		event.users = make(map[uint][]byte, len(env.UserData))
+		for userID, data := range env.UserData {
+			event.users[userID] = encode(seq, env.Type, data)
+		}
+	}
+
+	r.log.add(event)
+	for c := range r.clients {
+		if message := event.messageFor(c); message != nil {
+			r.queue(c, message)
+		}
+	}
+
+	if env.Disconnect != nil {
+		r.disconnect(*env.Disconnect)
+	}
+}
+
+// disconnect closes the connections of a player the host removed. They
+// already have the "kicked" message queued.
+func (r *room) disconnect(d disconnect) {
+	closeMessage := websocket.FormatCloseMessage(d.CloseCode, d.Reason)
+	for c := range r.clients {
+		if c.role() != RolePlayer || c.user.UserID != d.UserID {
+			continue
+		}
+		log.Printf("Disconnecting client %p of user %d from quiz %s", c, d.UserID, r.quizCode)
+		r.remove(c, closeMessage, false)
+	}
+	r.cancelRemoval(d.UserID)
+}
+
+// queue hands a message to a client's write pump. A client whose send buffer
+// is full is disconnected and told to reconnect with last_seq. Clients that
+// have left the room are skipped, since their send channel is closed.
+func (r *room) queue(c *Client, message []byte) {
+	if !r.clients[c] {
+		return
+	}
+	select {
+	case c.send <- message:
+		log.Printf("Queued message for client %p", c)
+	default:
+		log.Printf("Send channel full for client %p; disconnecting client", c)
+		r.remove(c, websocket.FormatCloseMessage(CloseLagging, "too far behind; reconnect with last_seq"), true)
+	}
+}
+
+// scheduleRemoval removes a disconnected player from the session once the
+// reconnect grace period has passed, unless they are still connected from
+// another client or reconnect before then.
+func (r *room) scheduleRemoval(c *Client) {
+	if c.role() != RolePlayer {
+		return
+	}
+	userID := c.user.UserID
+	for other := range r.clients {
+		if other.role() == RolePlayer && other.user.UserID == userID {
+			return
+		}
+	}
+
+	if timer, ok := r.removals[userID]; ok {
+		timer.Stop()
+	}
+	log.Printf("User %d disconnected from quiz %s; removing in %v unless they reconnect", userID, r.quizCode, reconnectGracePeriod)
+	var timer *time.Timer
+	timer = time.AfterFunc(reconnectGracePeriod, func() {
+		r.post(func(r *room) { r.removalDue(userID, timer) })
+	})
+	r.removals[userID] = timer
+}
+
+// removalDue removes a player whose grace period ran out.
+func (r *room) removalDue(userID uint, timer *time.Timer) {
+	if r.removals[userID] != timer {
+		return
+	}
+	delete(r.removals, userID)
+
+	// They may have reconnected to another instance.
+	members, err := r.hub.presence.Members(r.quizCode)
+	if err != nil {
+		log.Printf("Error listing members of quiz %s: %v", r.quizCode, err)
+		return
+	}
+	for _, member := range members {
+		if member.UserID == userID && !member.IsHost {
+			return
+		}
+	}
+	if err := r.hub.presence.Forget(r.quizCode, userID, false); err != nil {
+		log.Printf("Error forgetting room of user %d: %v", userID, err)
+	}
+	r.hub.removeParticipantFromDB(r.quizCode, userID)
+}
+
+// cancelRemoval keeps a player who reconnected in the session.
+func (r *room) cancelRemoval(userID uint) {
+	if timer, ok := r.removals[userID]; ok {
+		timer.Stop()
+		delete(r.removals, userID)
+		log.Printf("User %d reconnected to quiz %s", userID, r.quizCode)
+	}
+}
@@ -0,0 +1,320 @@
+package websocket
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeService records the players the hub removes from their session.
+type fakeService struct {
+	QuizServiceInterface
+	mu      sync.Mutex
+	removed []uint
+}
+
+func (s *fakeService) RemoveParticipant(quizCode string, userID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removed = append(s.removed, userID)
+	return nil
+}
+
+func (s *fakeService) removals() []uint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]uint(nil), s.removed...)
+}
+
+// newTestRoom returns a room the test drives itself, in place of the room
+// goroutine: it calls the room's methods directly and runs what was posted
+// with settle.
+func newTestRoom(t *testing.T) (*room, *fakeService) {
+	service := &fakeService{}
+	hub := NewHub()
+	hub.SetQuizService(service)
+	r := newRoom(hub, "ROOM")
+	t.Cleanup(func() {
+		for _, timer := range r.removals {
+			timer.Stop()
+		}
+		if r.unsubscribe != nil {
+			r.unsubscribe()
+		}
+	})
+	return r, service
+}
+
+// settle runs the operations posted to the room until none are left.
+func settle(r *room) {
+	for ops := r.take(); len(ops) > 0; ops = r.take() {
+		for _, op := range ops {
+			op(r)
+		}
+	}
+}
+
+func newTestClient(r *room, userID uint, isHost bool) *Client {
+	c := NewClient(r.hub, nil, r.quizCode)
+	c.user = &UserInfo{UserID: userID}
+	c.isHost = isHost
+	return c
+}
+
+// connected reports whether the user is in the room's presence.
+func connected(t *testing.T, r *room, userID uint) bool {
+	t.Helper()
+	members, err := r.hub.presence.Members(r.quizCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, member := range members {
+		if member.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// drained returns what was queued on a client that left, checking that its
+// send channel was closed.
+func drained(t *testing.T, c *Client) [][]byte {
+	t.Helper()
+	var messages [][]byte
+	for {
+		select {
+		case message, ok := <-c.send:
+			if !ok {
+				return messages
+			}
+			messages = append(messages, message)
+		default:
+			t.Fatal("the client's send channel was left open")
+		}
+	}
+}
+
+func TestRoomGracePeriod(t *testing.T) {
+	t.Run("player who does not return is removed", func(t *testing.T) {
+		r, service := newTestRoom(t)
+		c := newTestClient(r, 1, false)
+		r.join(c, 0, false)
+		settle(r)
+		if !connected(t, r, 1) {
+			t.Fatal("the player is not in the room's presence")
+		}
+
+		r.leave(c)
+		settle(r)
+		drained(t, c)
+		if connected(t, r, 1) {
+			t.Error("the player is still in the room's presence")
+		}
+		timer, ok := r.removals[1]
+		if !ok {
+			t.Fatal("no removal scheduled")
+		}
+		if got := service.removals(); len(got) != 0 {
+			t.Fatalf("removed %v before the grace period ran out", got)
+		}
+
+		timer.Stop()
+		r.removalDue(1, timer)
+		if got := service.removals(); len(got) != 1 || got[0] != 1 {
+			t.Errorf("removed %v, want [1]", got)
+		}
+		if len(r.removals) != 0 {
+			t.Error("the removal is still pending")
+		}
+	})
+
+	t.Run("player who reconnects stays", func(t *testing.T) {
+		r, service := newTestRoom(t)
+		c := newTestClient(r, 1, false)
+		r.join(c, 0, false)
+		r.leave(c)
+		timer := r.removals[1]
+
+		r.join(newTestClient(r, 1, false), 0, false)
+		settle(r)
+		if _, ok := r.removals[1]; ok {
+			t.Error("the removal is still pending after reconnecting")
+		}
+		// A timer that fired just before it was stopped changes nothing.
+		r.removalDue(1, timer)
+		if got := service.removals(); len(got) != 0 {
+			t.Errorf("removed %v, want nobody", got)
+		}
+	})
+
+	t.Run("player reconnected to another instance stays", func(t *testing.T) {
+		r, service := newTestRoom(t)
+		c := newTestClient(r, 1, false)
+		r.join(c, 0, false)
+		r.leave(c)
+		timer := r.removals[1]
+		timer.Stop()
+
+		if err := r.hub.presence.Join(r.quizCode, UserInfo{UserID: 1}, false); err != nil {
+			t.Fatal(err)
+		}
+		r.removalDue(1, timer)
+		if got := service.removals(); len(got) != 0 {
+			t.Errorf("removed %v, want nobody", got)
+		}
+	})
+
+	t.Run("player still connected from another client stays", func(t *testing.T) {
+		r, _ := newTestRoom(t)
+		first, second := newTestClient(r, 1, false), newTestClient(r, 1, false)
+		r.join(first, 0, false)
+		r.join(second, 0, false)
+		r.leave(first)
+		settle(r)
+		if _, ok := r.removals[1]; ok {
+			t.Error("a removal was scheduled while the player is connected")
+		}
+		if !connected(t, r, 1) {
+			t.Error("the player left the room's presence")
+		}
+	})
+
+	t.Run("host and spectators keep no place", func(t *testing.T) {
+		r, _ := newTestRoom(t)
+		host := newTestClient(r, 9, true)
+		spectator := newTestClient(r, 0, false)
+		spectator.spectator = true
+		r.join(host, 0, false)
+		r.join(spectator, 0, false)
+		r.leave(host)
+		r.leave(spectator)
+		settle(r)
+		if len(r.removals) != 0 {
+			t.Errorf("removals scheduled for %v", r.removals)
+		}
+	})
+}
+
+func TestRoomDisconnectsRemovedPlayer(t *testing.T) {
+	r, service := newTestRoom(t)
+	kicked, other := newTestClient(r, 1, false), newTestClient(r, 2, false)
+	r.join(kicked, 0, false)
+	r.join(other, 0, false)
+	settle(r)
+
+	r.hub.DisconnectUser(r.quizCode, 1, CloseKicked, "spam")
+	settle(r)
+
+	if r.clients[kicked] || !r.clients[other] {
+		t.Fatal("the wrong client was disconnected")
+	}
+	messages := drained(t, kicked)
+	if len(messages) == 0 || !bytes.Contains(messages[len(messages)-1], []byte(`"kicked"`)) {
+		t.Error("the player was not told they were kicked before the connection closed")
+	}
+	if kicked.closeMessage == nil {
+		t.Error("no close frame for the kicked player")
+	}
+	// The service removes a kicked player itself; the room does not wait
+	// for them to come back.
+	if _, ok := r.removals[1]; ok {
+		t.Error("a removal was scheduled for the kicked player")
+	}
+	if got := service.removals(); len(got) != 0 {
+		t.Errorf("removed %v, want nobody", got)
+	}
+}
+
+func TestHubRunsRoomOperationsInOrder(t *testing.T) {
+	hub := NewHub()
+	wait := func() {
+		done := make(chan struct{})
+		hub.Dispatch("ROOM", func() { close(done) })
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("the room did not run its operations")
+		}
+	}
+
+	t.Run("in the order they were posted", func(t *testing.T) {
+		var got []int
+		for i := 0; i < 100; i++ {
+			i := i
+			hub.Dispatch("ROOM", func() { got = append(got, i) })
+		}
+		wait()
+		for i, n := range got {
+			if n != i {
+				t.Fatalf("operation %d ran as number %d", n, i)
+			}
+		}
+		if len(got) != 100 {
+			t.Errorf("%d operations ran, want 100", len(got))
+		}
+	})
+
+	t.Run("one at a time", func(t *testing.T) {
+		var running, overlaps int32
+		var posters sync.WaitGroup
+		for p := 0; p < 4; p++ {
+			posters.Add(1)
+			go func() {
+				defer posters.Done()
+				for i := 0; i < 25; i++ {
+					hub.Dispatch("ROOM", func() {
+						if atomic.AddInt32(&running, 1) > 1 {
+							atomic.AddInt32(&overlaps, 1)
+						}
+						time.Sleep(100 * time.Microsecond)
+						atomic.AddInt32(&running, -1)
+					})
+				}
+			}()
+		}
+		posters.Wait()
+		wait()
+		if overlaps != 0 {
+			t.Errorf("%d operations ran alongside another", overlaps)
+		}
+	})
+
+	t.Run("posted from the room itself", func(t *testing.T) {
+		var got []string
+		done := make(chan struct{})
+		hub.Dispatch("ROOM", func() {
+			hub.Dispatch("ROOM", func() {
+				got = append(got, "nested")
+				close(done)
+			})
+			got = append(got, "outer")
+		})
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("the nested operation did not run")
+		}
+		if len(got) != 2 || got[0] != "outer" || got[1] != "nested" {
+			t.Errorf("ran %v, want [outer nested]", got)
+		}
+	})
+
+	t.Run("after the idle room stopped", func(t *testing.T) {
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			hub.mu.Lock()
+			_, running := hub.rooms["ROOM"]
+			hub.mu.Unlock()
+			if !running {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("the idle room kept running")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		wait()
+	})
+}
@@ -0,0 +1,198 @@
+package websocket
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema describes the protocol as a JSON Schema (draft 2020-12) built from
+// the payload types of its messages. Every payload struct becomes a
+// definition under $defs; ClientMessage and ServerMessage match any message
+// a client or the server sends.
+func Schema(client, server []MessageSpec) map[string]interface{} {
+	g := &schemaGenerator{
+		defs:  make(map[string]interface{}),
+		names: make(map[reflect.Type]string),
+		enums: map[reflect.Type][]string{
+			reflect.TypeOf(ErrorCode("")): errorCodeNames(),
+		},
+	}
+	g.defs["ClientMessage"] = map[string]interface{}{"oneOf": g.messages(client, false)}
+	g.defs["ServerMessage"] = map[string]interface{}{"oneOf": g.messages(server, true)}
+
+	return map[string]interface{}{
+		"$schema":        "https://json-schema.org/draft/2020-12/schema",
+		"title":          "Quiz WebSocket protocol",
+		"x-protocol":     ProtocolVersion,
+		"x-min-protocol": MinProtocolVersion,
+		"$defs":          g.defs,
+		"oneOf": []interface{}{
+			ref("ClientMessage"),
+			ref("ServerMessage"),
+		},
+	}
+}
+
+func errorCodeNames() []string {
+	names := make([]string, len(ErrorCodes))
+	for i, code := range ErrorCodes {
+		names[i] = string(code)
+	}
+	return names
+}
+
+func ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/$defs/" + name}
+}
+
+// schemaGenerator turns Go types into schemas, collecting named structs and
+// enums as definitions.
+type schemaGenerator struct {
+	defs  map[string]interface{}
+	names map[reflect.Type]string
+	enums map[reflect.Type][]string
+}
+
+// messages builds the schema of each message: its type, its payload and, for
+// server messages, its sequence number.
+func (g *schemaGenerator) messages(specs []MessageSpec, fromServer bool) []interface{} {
+	schemas := make([]interface{}, 0, len(specs))
+	for _, spec := range specs {
+		data := map[string]interface{}{"type": "null"}
+		if spec.Data != nil {
+			data = g.schemaOf(reflect.TypeOf(spec.Data))
+		}
+		properties := map[string]interface{}{
+			"type": map[string]interface{}{"const": spec.Type},
+			"data": data,
+		}
+		// Clients may leave out empty data; the server always sends it.
+		required := []string{"type"}
+		if fromServer {
+			required = append(required, "data")
+			properties["seq"] = map[string]interface{}{
+				"type":        "integer",
+				"minimum":     1,
+				"description": "Position of the message in its room; absent on replies to the connection itself.",
+			}
+		}
+		schemas = append(schemas, map[string]interface{}{
+			"title":       spec.Type,
+			"description": spec.Description,
+			"type":        "object",
+			"properties":  properties,
+			"required":    required,
+		})
+	}
+	return schemas
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaOf returns the schema of a type, or a reference to its definition.
+func (g *schemaGenerator) schemaOf(t reflect.Type) map[string]interface{} {
+	if values, ok := g.enums[t]; ok {
+		return g.define(t, func() map[string]interface{} {
+			return map[string]interface{}{"type": "string", "enum": values}
+		})
+	}
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case rawType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Ptr:
+		return map[string]interface{}{"anyOf": []interface{}{g.schemaOf(t.Elem()), map[string]interface{}{"type": "null"}}}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return g.define(t, func() map[string]interface{} { return g.structSchema(t) })
+	}
+	return map[string]interface{}{}
+}
+
+// define adds a named type to the definitions once and refers to it.
+func (g *schemaGenerator) define(t reflect.Type, build func() map[string]interface{}) map[string]interface{} {
+	if name, ok := g.names[t]; ok {
+		return ref(name)
+	}
+	name := t.Name()
+	if _, taken := g.defs[name]; taken {
+		name = strings.ReplaceAll(t.String(), ".", "_")
+	}
+	g.names[t] = name
+	g.defs[name] = nil // reserve the name for recursive types
+	g.defs[name] = build()
+	return ref(name)
+}
+
+// structSchema describes a struct by its JSON fields. Fields tagged
+// omitempty are optional, so their pointers are not nullable.
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := make([]string, 0)
+	g.addFields(t, properties, &required)
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			g.addFields(field.Type, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldType := field.Type
+		omitEmpty := strings.Contains(options, "omitempty")
+		if omitEmpty && fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		properties[name] = g.schemaOf(fieldType)
+		if !omitEmpty {
+			*required = append(*required, name)
+		}
+	}
+}